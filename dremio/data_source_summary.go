@@ -5,8 +5,6 @@ import (
 	"strconv"
 	"time"
 
-	dapi "github.com/saltxwater/go-dremio-api-client"
-
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 )
@@ -36,7 +34,7 @@ func dataSourceSummary() *schema.Resource {
 }
 
 func dataSourceSummaryRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	client := m.(*dapi.Client)
+	client := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
@@ -36,16 +36,21 @@ func Provider() *schema.Provider {
 			},
 		},
 		ResourcesMap: map[string]*schema.Resource{
-			"dremio_folder":           resourceFolder(),
-			"dremio_space":            resourceSpace(),
-			"dremio_source":           resourceSource(),
-			"dremio_virtual_dataset":  resourceVirtualDataset(),
-			"dremio_promoted_dataset": resourcePromotedDataset(),
-			"dremio_physical_dataset": resourcePhysicalDataset(),
-			"dremio_raw_reflection":   resourceRawReflection(),
-			"dremio_aggr_reflection":  resourceAggregationReflection(),
-			"dremio_entity_tags":      resourceEntityTags(),
-			"dremio_entity_wiki":      resourceEntityWiki(),
+			"dremio_folder":              resourceFolder(),
+			"dremio_source_folder":       resourceSourceFolder(),
+			"dremio_space":               resourceSpace(),
+			"dremio_source":              resourceSource(),
+			"dremio_virtual_dataset":     resourceVirtualDataset(),
+			"dremio_promoted_dataset":    resourcePromotedDataset(),
+			"dremio_physical_dataset":    resourcePhysicalDataset(),
+			"dremio_raw_reflection":      resourceRawReflection(),
+			"dremio_aggr_reflection":     resourceAggregationReflection(),
+			"dremio_external_reflection": resourceExternalReflection(),
+			"dremio_entity_tags":         resourceEntityTags(),
+			"dremio_entity_tag":          resourceEntityTag(),
+			"dremio_entity_wiki":         resourceEntityWiki(),
+			"dremio_dataset_doc":         resourceDatasetDoc(),
+			"dremio_reflection_refresh":  resourceReflectionRefresh(),
 		},
 		DataSourcesMap: map[string]*schema.Resource{
 			"dremio_summary": dataSourceSummary(),
@@ -54,6 +59,13 @@ func Provider() *schema.Provider {
 	}
 }
 
+// Passed to resources as meta. The api connection covers the few REST
+// endpoints the client does not wrap.
+type providerMeta struct {
+	client *dapi.Client
+	api    *apiConnection
+}
+
 func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}, diag.Diagnostics) {
 	username := d.Get("username").(string)
 	password := d.Get("password").(string)
@@ -71,5 +83,8 @@ func providerConfigure(ctx context.Context, d *schema.ResourceData) (interface{}
 	if err != nil {
 		return nil, diag.FromErr(err)
 	}
-	return client, diags
+	return &providerMeta{
+		client: client,
+		api:    newApiConnection(baseUrl, config),
+	}, diags
 }
@@ -2,20 +2,26 @@ package dremio
 
 import (
 	"context"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	dapi "github.com/saltxwater/go-dremio-api-client"
 )
 
 func resourceAggregationReflection() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		CreateContext: resourceAggregationReflectionCreate,
 		ReadContext:   resourceAggregationReflectionRead,
 		UpdateContext: resourceAggregationReflectionUpdate,
 		DeleteContext: resourceAggregationReflectionDelete,
-		Schema: map[string]*schema.Schema{
+		CustomizeDiff: resourceAggregationReflectionCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+		},
+		Schema: makeReflectionStatusSchema(makeReflectionPartitionSchema(map[string]*schema.Schema{
 			"dataset_id": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -45,21 +51,47 @@ func resourceAggregationReflection() *schema.Resource {
 				},
 			},
 			"measure_fields_sum": {
-				Type:     schema.TypeList,
-				Optional: true,
+				Type:          schema.TypeList,
+				Optional:      true,
+				Deprecated:    "Use measure blocks with types = [\"SUM\"] instead",
+				ConflictsWith: []string{"measure"},
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
 				},
 			},
-			"distribution_fields": {
-				Type:     schema.TypeList,
-				Optional: true,
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
+			"measure": {
+				Type:          schema.TypeList,
+				Optional:      true,
+				ConflictsWith: []string{"measure_fields_sum"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"field": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"types": {
+							Type:     schema.TypeSet,
+							Required: true,
+							MinItems: 1,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+								ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
+									v := val.(string)
+									for _, t := range reflectionMeasureTypes {
+										if v == t {
+											return
+										}
+									}
+									errs = append(errs, fmt.Errorf("%s must be one of %s, got: %s", key, strings.Join(reflectionMeasureTypes, ", "), v))
+									return
+								},
+							},
+						},
+					},
 				},
 			},
-			"partition_fields": {
-				Type:     schema.TypeList,
+			"distribution_fields": {
+				Type:     schema.TypeSet,
 				Optional: true,
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
@@ -77,35 +109,40 @@ func resourceAggregationReflection() *schema.Resource {
 				Optional: true,
 				Default:  "CONSOLIDATED",
 			},
+		})),
+	}
+	r.SchemaVersion = 1
+	r.StateUpgraders = []schema.StateUpgrader{
+		{
+			Version: 0,
+			Type:    resourceAggregationReflectionV0().CoreConfigSchema().ImpliedType(),
+			Upgrade: upgradeReflectionStateV0,
 		},
 	}
+	return r
 }
 
-func getDimensionFields(d *schema.ResourceData) []dapi.ReflectionFieldWithGranularity {
+func getDimensionFields(d *schema.ResourceData) []apiReflectionDimensionField {
 	dimFields := d.Get("dimension_fields").([]interface{})
 	lenDimFields := len(dimFields)
 	tdDimFields := d.Get("timestamp_date_dimension_fields").([]interface{})
-	items := make([]dapi.ReflectionFieldWithGranularity, lenDimFields+len(tdDimFields))
+	items := make([]apiReflectionDimensionField, lenDimFields+len(tdDimFields))
 	for i, name := range dimFields {
-		items[i] = dapi.ReflectionFieldWithGranularity{
-			ReflectionField: dapi.ReflectionField{
-				Name: name.(string),
-			},
+		items[i] = apiReflectionDimensionField{
+			Name:        name.(string),
 			Granularity: "NORMAL",
 		}
 	}
 	for i, name := range tdDimFields {
-		items[lenDimFields+i] = dapi.ReflectionFieldWithGranularity{
-			ReflectionField: dapi.ReflectionField{
-				Name: name.(string),
-			},
+		items[lenDimFields+i] = apiReflectionDimensionField{
+			Name:        name.(string),
 			Granularity: "DATE",
 		}
 	}
 	return items
 }
 
-func setDimensionFields(d *schema.ResourceData, fields []dapi.ReflectionFieldWithGranularity) error {
+func setDimensionFields(d *schema.ResourceData, fields []apiReflectionDimensionField) error {
 	dimFields := make([]string, 0)
 	tdDimFields := make([]string, 0)
 	for _, field := range fields {
@@ -123,78 +160,177 @@ func setDimensionFields(d *schema.ResourceData, fields []dapi.ReflectionFieldWit
 	return d.Set("timestamp_date_dimension_fields", tdDimFields)
 }
 
-func getMeasureFields(d *schema.ResourceData) []dapi.ReflectionMeasureField {
-	itemsRaw := d.Get("measure_fields_sum").([]interface{})
-	items := make([]dapi.ReflectionMeasureField, len(itemsRaw))
-	for i, raw := range itemsRaw {
-		items[i] = dapi.ReflectionMeasureField{
-			ReflectionField: dapi.ReflectionField{
-				Name: raw.(string),
-			},
-			MeasureTypeList: []string{"SUM"},
+var reflectionMeasureTypes = []string{"SUM", "COUNT", "MIN", "MAX", "APPROX_COUNT_DISTINCT"}
+
+func getMeasureFields(d *schema.ResourceData) []apiReflectionMeasureField {
+	items := make([]apiReflectionMeasureField, 0)
+	indexes := make(map[string]int)
+	addMeasure := func(name string, measureType string) {
+		i, ok := indexes[name]
+		if !ok {
+			i = len(items)
+			indexes[name] = i
+			items = append(items, apiReflectionMeasureField{
+				Name:            name,
+				MeasureTypeList: []string{},
+			})
+		}
+		for _, existing := range items[i].MeasureTypeList {
+			if existing == measureType {
+				return
+			}
+		}
+		items[i].MeasureTypeList = append(items[i].MeasureTypeList, measureType)
+	}
+
+	for _, raw := range d.Get("measure_fields_sum").([]interface{}) {
+		addMeasure(raw.(string), "SUM")
+	}
+	for _, raw := range d.Get("measure").([]interface{}) {
+		measure := raw.(map[string]interface{})
+		for _, measureType := range measure["types"].(*schema.Set).List() {
+			addMeasure(measure["field"].(string), measureType.(string))
 		}
 	}
 	return items
 }
 
-func setMeasureFields(d *schema.ResourceData, fields []dapi.ReflectionMeasureField) error {
-	items := make([]string, 0)
-	for _, raw := range fields {
-		for _, m := range raw.MeasureTypeList {
-			if m == "SUM" {
-				items = append(items, raw.Name)
+// Puts the measures the server returns into the order of the configuration,
+// so a reordered response is not reported as a change. Measures not in the
+// configuration follow in server order.
+func orderMeasureFields(d *schema.ResourceData, fields []apiReflectionMeasureField) []apiReflectionMeasureField {
+	configured := make([]string, 0)
+	for _, raw := range d.Get("measure_fields_sum").([]interface{}) {
+		configured = append(configured, raw.(string))
+	}
+	for _, raw := range d.Get("measure").([]interface{}) {
+		configured = append(configured, raw.(map[string]interface{})["field"].(string))
+	}
+
+	ordered := make([]apiReflectionMeasureField, 0, len(fields))
+	used := make([]bool, len(fields))
+	for _, name := range configured {
+		for i, field := range fields {
+			if !used[i] && field.Name == name {
+				ordered = append(ordered, field)
+				used[i] = true
+				break
 			}
 		}
 	}
-	return d.Set("measure_fields_sum", items)
+	for i, field := range fields {
+		if !used[i] {
+			ordered = append(ordered, field)
+		}
+	}
+	return ordered
+}
+
+func setMeasureFields(d *schema.ResourceData, fields []apiReflectionMeasureField) error {
+	fields = orderMeasureFields(d, fields)
+
+	// Keep the deprecated shorthand in sync for configurations still using it
+	if len(d.Get("measure").([]interface{})) == 0 && len(d.Get("measure_fields_sum").([]interface{})) > 0 {
+		items := make([]string, 0)
+		for _, raw := range fields {
+			for _, m := range raw.MeasureTypeList {
+				if m == "SUM" {
+					items = append(items, raw.Name)
+				}
+			}
+		}
+		return d.Set("measure_fields_sum", items)
+	}
+
+	measures := make([]interface{}, len(fields))
+	for i, raw := range fields {
+		measures[i] = map[string]interface{}{
+			"field": raw.Name,
+			"types": raw.MeasureTypeList,
+		}
+	}
+	return d.Set("measure", measures)
+}
+
+func resourceAggregationReflectionCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	return validateAggregationReflectionFields(ctx, m.(*providerMeta).api, d)
+}
+
+func validateAggregationReflectionFields(ctx context.Context, api *apiConnection, d reflectionConfig) error {
+	refs := collectReflectionFieldRefs(d, "dimension_fields", "timestamp_date_dimension_fields", "measure_fields_sum",
+		"distribution_fields", "partition_fields", "sort_fields")
+	if reflectionValueKnown(d, "measure") {
+		// Each field is sent as a single measure, so it must be configured once
+		seen := make(map[string]int)
+		for i, raw := range d.Get("measure").([]interface{}) {
+			measure := raw.(map[string]interface{})
+			field := measure["field"].(string)
+			if first, ok := seen[field]; ok && field != "" {
+				return fmt.Errorf("measure.%d.field: field '%s' is already measured by measure.%d, list all its types in one block", i, field, first)
+			}
+			seen[field] = i
+			refs = append(refs, reflectionFieldRef{
+				key:  "measure",
+				path: fmt.Sprintf("measure.%d.field", i),
+				name: measure["field"].(string),
+			})
+		}
+	}
+	partitionRefs, err := collectPartitionFieldRefs(d)
+	if err != nil {
+		return err
+	}
+	return validateReflectionFields(ctx, api, d, append(refs, partitionRefs...))
+}
+
+func getAggregationReflectionSpec(d *schema.ResourceData) *apiReflectionSpec {
+	return &apiReflectionSpec{
+		Type:                          "AGGREGATION",
+		Name:                          d.Get("name").(string),
+		DatasetId:                     d.Get("dataset_id").(string),
+		Enabled:                       d.Get("enabled").(bool),
+		DimensionFields:               getDimensionFields(d),
+		MeasureFields:                 getMeasureFields(d),
+		DistributionFields:            interfaceListToReflectionFieldList(d.Get("distribution_fields").(*schema.Set).List()),
+		PartitionFields:               getPartitionFields(d),
+		SortFields:                    interfaceListToReflectionFieldList(d.Get("sort_fields").([]interface{})),
+		PartitionDistributionStrategy: d.Get("partition_distribution_strategy").(string),
+	}
 }
 
 func resourceAggregationReflectionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	api := m.(*providerMeta).api
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
-	datasetId := d.Get("dataset_id").(string)
-	name := d.Get("name").(string)
-	enabled := d.Get("enabled").(bool)
-	dimensionFields := getDimensionFields(d)
-	measureFields := getMeasureFields(d)
-	distributionFields := interfaceListToReflectionFieldList(d.Get("distribution_fields").([]interface{}))
-	partitionFields := interfaceListToReflectionFieldList(d.Get("partition_fields").([]interface{}))
-	sortFields := interfaceListToReflectionFieldList(d.Get("sort_fields").([]interface{}))
-	partitionDistributionStrategy := d.Get("partition_distribution_strategy").(string)
-
-	res, err := c.NewAggregationReflection(datasetId, &dapi.AggregationReflectionSpec{
-		Name:                          name,
-		Enabled:                       enabled,
-		DimensionFields:               dimensionFields,
-		MeasureFields:                 measureFields,
-		DistributionFields:            distributionFields,
-		PartitionFields:               partitionFields,
-		SortFields:                    sortFields,
-		PartitionDistributionStrategy: partitionDistributionStrategy,
-	})
+	if err := validateAggregationReflectionFields(ctx, api, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	res, err := api.createReflection(ctx, getAggregationReflectionSpec(d))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	d.SetId(res.Id)
 
-	resourceAggregationReflectionRead(ctx, d, m)
+	if err := waitForReflectionMaterialization(ctx, api, d, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
 
-	return diags
+	return append(diags, resourceAggregationReflectionRead(ctx, d, m)...)
 }
 
 func resourceAggregationReflectionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	api := m.(*providerMeta).api
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	resId := d.Id()
 
-	res, err := c.GetAggregationReflection(resId)
+	res, err := api.getReflection(ctx, resId)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -210,51 +346,44 @@ func resourceAggregationReflectionRead(ctx context.Context, d *schema.ResourceDa
 	if err := d.Set("distribution_fields", reflectionFieldListToStringList(res.DistributionFields)); err != nil {
 		return diag.FromErr(err)
 	}
-	if err := d.Set("partition_fields", reflectionFieldListToStringList(res.PartitionFields)); err != nil {
+	if err := setPartitionFields(d, res.PartitionFields); err != nil {
 		return diag.FromErr(err)
 	}
 	if err := d.Set("sort_fields", reflectionFieldListToStringList(res.SortFields)); err != nil {
 		return diag.FromErr(err)
 	}
 	d.Set("partition_distribution_strategy", res.PartitionDistributionStrategy)
+	if err := readReflectionStatus(d, res); err != nil {
+		return diag.FromErr(err)
+	}
 
 	return diags
 }
 
 func resourceAggregationReflectionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	api := m.(*providerMeta).api
 
 	resId := d.Id()
 
-	name := d.Get("name").(string)
-	enabled := d.Get("enabled").(bool)
-	dimensionFields := getDimensionFields(d)
-	measureFields := getMeasureFields(d)
-	distributionFields := interfaceListToReflectionFieldList(d.Get("distribution_fields").([]interface{}))
-	partitionFields := interfaceListToReflectionFieldList(d.Get("partition_fields").([]interface{}))
-	sortFields := interfaceListToReflectionFieldList(d.Get("sort_fields").([]interface{}))
-	partitionDistributionStrategy := d.Get("partition_distribution_strategy").(string)
-
-	_, err := c.UpdateAggregationReflection(resId, &dapi.AggregationReflectionSpec{
-		Name:                          name,
-		Enabled:                       enabled,
-		DimensionFields:               dimensionFields,
-		MeasureFields:                 measureFields,
-		DistributionFields:            distributionFields,
-		PartitionFields:               partitionFields,
-		SortFields:                    sortFields,
-		PartitionDistributionStrategy: partitionDistributionStrategy,
-	})
+	if err := validateAggregationReflectionFields(ctx, api, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	err := api.updateReflection(ctx, resId, getAggregationReflectionSpec(d))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	d.Set("last_updated", time.Now().Format(time.RFC850))
 
+	if err := waitForReflectionMaterialization(ctx, api, d, d.Timeout(schema.TimeoutUpdate)); err != nil {
+		return diag.FromErr(err)
+	}
+
 	return resourceAggregationReflectionRead(ctx, d, m)
 }
 
 func resourceAggregationReflectionDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
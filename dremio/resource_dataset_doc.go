@@ -0,0 +1,269 @@
+package dremio
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	dapi "github.com/saltxwater/go-dremio-api-client"
+)
+
+const defaultDatasetDocTemplate = `# {{ .QueryPath }}
+{{ if .Sql }}
+## Definition
+
+` + "```sql" + `
+{{ .Sql }}
+` + "```" + `
+{{ end }}
+## Fields
+
+| Name | Type |
+| --- | --- |
+{{ range .Fields }}| {{ .Name }} | {{ .Type.Name }} |
+{{ end }}{{ if .Reflections }}
+## Reflections
+
+| Name | Type | Enabled |
+| --- | --- | --- |
+{{ range .Reflections }}| {{ .Name }} | {{ .Type }} | {{ .Enabled }} |
+{{ end }}{{ end }}{{ if .Tags }}
+Tags: {{ join .Tags ", " }}
+{{ end }}`
+
+type datasetDocFieldType struct {
+	Name string
+}
+
+type datasetDocField struct {
+	Name string
+	Type datasetDocFieldType
+}
+
+// Only the settings of a reflection are listed, its status changes too often
+// to be kept in the wiki.
+type datasetDocReflection struct {
+	Name    string
+	Type    string
+	Enabled bool
+}
+
+type datasetDocData struct {
+	Id          string
+	Path        []string
+	QueryPath   string
+	Sql         string
+	SqlContext  []string
+	Fields      []datasetDocField
+	Reflections []datasetDocReflection
+	Tags        []string
+}
+
+func resourceDatasetDoc() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceDatasetDocCreate,
+		ReadContext:   resourceDatasetDocRead,
+		UpdateContext: resourceDatasetDocUpdate,
+		DeleteContext: resourceDatasetDocDelete,
+		CustomizeDiff: resourceDatasetDocCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"dataset_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"template": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  defaultDatasetDocTemplate,
+				Description: "Go template for the document, given the dataset's `Id`, `Path`, `QueryPath`, `Sql`, `SqlContext`, `Fields`, " +
+					"`Reflections` and `Tags`. Each reflection has a `Name`, `Type` and `Enabled`.",
+			},
+			"text": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "The rendered document. It is kept in its own marked section of the dataset's wiki, the rest of the wiki is left alone.",
+			},
+		},
+	}
+}
+
+func getDatasetDocData(ctx context.Context, c *dapi.Client, api *apiConnection, datasetId string) (*datasetDocData, error) {
+	data := &datasetDocData{
+		Id: datasetId,
+	}
+	ds, vds, err := getDatasetById(c, datasetId)
+	if err != nil {
+		return nil, err
+	}
+	if vds != nil {
+		data.Sql = vds.Sql
+		data.SqlContext = vds.SqlContext
+	}
+	data.Path = ds.Path
+	data.QueryPath = getQueryPath(ds.Path)
+
+	data.Fields = make([]datasetDocField, len(ds.Fields))
+	for i, field := range ds.Fields {
+		data.Fields[i] = datasetDocField{
+			Name: field.Name,
+			Type: datasetDocFieldType{Name: field.Type.Name},
+		}
+	}
+
+	reflections, err := api.getDatasetReflections(ctx, datasetId)
+	if err != nil {
+		return nil, err
+	}
+	data.Reflections = make([]datasetDocReflection, len(reflections))
+	for i, reflection := range reflections {
+		data.Reflections[i] = datasetDocReflection{
+			Name:    reflection.Name,
+			Type:    reflection.Type,
+			Enabled: reflection.Enabled,
+		}
+	}
+
+	tagBody, err := c.GetEntityTags(datasetId)
+	if err == nil {
+		data.Tags = tagBody.Tags
+	}
+	return data, nil
+}
+
+func renderDatasetDoc(ctx context.Context, c *dapi.Client, api *apiConnection, datasetId string, text string) (string, error) {
+	data, err := getDatasetDocData(ctx, c, api, datasetId)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New("dataset_doc").Funcs(template.FuncMap{
+		"join": strings.Join,
+	}).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var doc strings.Builder
+	if err := tmpl.Execute(&doc, data); err != nil {
+		return "", err
+	}
+	return doc.String(), nil
+}
+
+// Renders the document on every plan so changes to the dataset's schema, SQL
+// or tags show up as a diff on text and are written back on apply.
+func resourceDatasetDocCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	c := m.(*providerMeta).client
+
+	if !d.NewValueKnown("dataset_id") || !d.NewValueKnown("template") {
+		return d.SetNewComputed("text")
+	}
+	datasetId := d.Get("dataset_id").(string)
+	if d.Id() == "" {
+		// The dataset may not exist until it is created in this apply
+		if _, err := c.GetCatalogEntityById(datasetId); err != nil {
+			return d.SetNewComputed("text")
+		}
+	}
+	text, err := renderDatasetDoc(ctx, c, m.(*providerMeta).api, datasetId, d.Get("template").(string))
+	if err != nil {
+		return err
+	}
+	if text != d.Get("text").(string) {
+		return d.SetNew("text", text)
+	}
+	return nil
+}
+
+func writeDatasetDoc(ctx context.Context, c *dapi.Client, api *apiConnection, d *schema.ResourceData, datasetId string) error {
+	text, err := renderDatasetDoc(ctx, c, api, datasetId, d.Get("template").(string))
+	if err != nil {
+		return err
+	}
+	current := ""
+	wikiVersion := 0
+	wikiBody, err := c.GetEntityWiki(datasetId)
+	if err != nil {
+		// Assume failed because no wiki exists yet
+	} else {
+		current = wikiBody.Text
+		wikiVersion = wikiBody.Version
+	}
+	// Only the generated section is owned here, the rest of the wiki is kept
+	return c.SetEntityWiki(datasetId, wikiDocSection.merge(current, wikiDocSection.wrap(text)), wikiVersion)
+}
+
+func resourceDatasetDocCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	datasetId := d.Get("dataset_id").(string)
+	if err := writeDatasetDoc(ctx, c, m.(*providerMeta).api, d, datasetId); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(datasetId)
+
+	return resourceDatasetDocRead(ctx, d, m)
+}
+
+func resourceDatasetDocRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	api := m.(*providerMeta).api
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	datasetId := d.Id()
+
+	// A wiki deleted outside of Terraform reads as an empty document, so the
+	// plan writes it again
+	wikiText, err := api.getEntityWikiText(ctx, datasetId)
+	if isApiStatus(err, http.StatusNotFound) {
+		wikiText = ""
+	} else if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("dataset_id", datasetId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("text", wikiDocSection.unwrap(wikiText)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
+
+func resourceDatasetDocUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	if err := writeDatasetDoc(ctx, c, m.(*providerMeta).api, d, d.Id()); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("last_updated", time.Now().Format(time.RFC850))
+
+	return resourceDatasetDocRead(ctx, d, m)
+}
+
+func resourceDatasetDocDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	datasetId := d.Id()
+	wikiBody, err := c.GetEntityWiki(datasetId)
+	if err == nil {
+		err := c.SetEntityWiki(datasetId, wikiDocSection.merge(wikiBody.Text, ""), wikiBody.Version)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	d.SetId("")
+
+	return diags
+}
@@ -0,0 +1,164 @@
+package dremio
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	dapi "github.com/saltxwater/go-dremio-api-client"
+)
+
+func resourceEntityTag() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceEntityTagCreate,
+		ReadContext:   resourceEntityTagRead,
+		DeleteContext: resourceEntityTagDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(1 * time.Minute),
+			Delete: schema.DefaultTimeout(1 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"entity_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"tag": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Tag to add. Creation fails if the entity already has the tag, so a tag added by someone else is never removed on destroy.",
+			},
+		},
+	}
+}
+
+// Reads the entity's tags. Dremio reports an entity without tags the same way
+// as a failed lookup, so the entity itself is checked before the error is
+// treated as no tags.
+func getEntityTagsOrEmpty(c *dapi.Client, entityId string) ([]string, string, error) {
+	tagBody, err := c.GetEntityTags(entityId)
+	if err == nil {
+		return tagBody.Tags, tagBody.Version, nil
+	}
+	if _, entityErr := c.GetCatalogEntityById(entityId); entityErr != nil {
+		return nil, "", err
+	}
+	return []string{}, "", nil
+}
+
+// Applies modify to the entity's current tags and writes them back with the
+// version they were read at. A write that fails because the tags changed in
+// the meantime is retried against a fresh read, so tags added or removed by
+// others are kept. Any other failure is returned straight away.
+func modifyEntityTags(ctx context.Context, c *dapi.Client, entityId string, timeout time.Duration, modify func([]string) ([]string, error)) error {
+	deadline := time.Now().Add(timeout)
+	for attempt := 1; ; attempt++ {
+		tags, tagVersion, err := getEntityTagsOrEmpty(c, entityId)
+		if err != nil {
+			return err
+		}
+		modified, err := modify(tags)
+		if err != nil {
+			return err
+		}
+
+		err = c.SetEntityTags(entityId, modified, tagVersion)
+		if err == nil {
+			return nil
+		}
+		_, currentVersion, readErr := getEntityTagsOrEmpty(c, entityId)
+		if readErr != nil || currentVersion == tagVersion {
+			return err
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		log.Printf("Tags on entity '%s' changed from version '%s' to '%s', retrying: %s", entityId, tagVersion, currentVersion, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+}
+
+func resourceEntityTagCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	entityId := d.Get("entity_id").(string)
+	tag := d.Get("tag").(string)
+
+	err := modifyEntityTags(ctx, c, entityId, d.Timeout(schema.TimeoutCreate), func(tags []string) ([]string, error) {
+		for _, t := range tags {
+			if t == tag {
+				// Taking over the tag would remove it on destroy
+				return nil, fmt.Errorf("tag '%s' is already present on entity '%s' and is not managed by this resource", tag, entityId)
+			}
+		}
+		return append(tags, tag), nil
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(fmt.Sprintf("%s/%s", entityId, tag))
+
+	return resourceEntityTagRead(ctx, d, m)
+}
+
+func resourceEntityTagRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	entityId := d.Get("entity_id").(string)
+	tag := d.Get("tag").(string)
+
+	tags, _, err := getEntityTagsOrEmpty(c, entityId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	for _, t := range tags {
+		if t == tag {
+			return diags
+		}
+	}
+
+	log.Printf("Tag '%s' no longer present on entity '%s'", tag, entityId)
+	d.SetId("")
+
+	return diags
+}
+
+func resourceEntityTagDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	entityId := d.Get("entity_id").(string)
+	tag := d.Get("tag").(string)
+
+	err := modifyEntityTags(ctx, c, entityId, d.Timeout(schema.TimeoutDelete), func(tags []string) ([]string, error) {
+		remaining := make([]string, 0, len(tags))
+		for _, t := range tags {
+			if t != tag {
+				remaining = append(remaining, t)
+			}
+		}
+		return remaining, nil
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
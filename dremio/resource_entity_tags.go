@@ -6,7 +6,6 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	dapi "github.com/saltxwater/go-dremio-api-client"
 )
 
 func resourceEntityTags() *schema.Resource {
@@ -22,7 +21,7 @@ func resourceEntityTags() *schema.Resource {
 				ForceNew: true,
 			},
 			"tags": {
-				Type:     schema.TypeList,
+				Type:     schema.TypeSet,
 				Required: true,
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
@@ -33,7 +32,7 @@ func resourceEntityTags() *schema.Resource {
 }
 
 func getTags(d *schema.ResourceData) []string {
-	rawTags := d.Get("tags").([]interface{})
+	rawTags := d.Get("tags").(*schema.Set).List()
 	tags := make([]string, len(rawTags))
 	for i, name := range rawTags {
 		tags[i] = name.(string)
@@ -42,7 +41,7 @@ func getTags(d *schema.ResourceData) []string {
 }
 
 func resourceEntityTagsCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	entityId := d.Get("entity_id").(string)
 	tags := getTags(d)
@@ -66,7 +65,7 @@ func resourceEntityTagsCreate(ctx context.Context, d *schema.ResourceData, m int
 }
 
 func resourceEntityTagsRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
@@ -84,7 +83,7 @@ func resourceEntityTagsRead(ctx context.Context, d *schema.ResourceData, m inter
 }
 
 func resourceEntityTagsUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	entityId := d.Id()
 	tags := getTags(d)
@@ -104,7 +103,7 @@ func resourceEntityTagsUpdate(ctx context.Context, d *schema.ResourceData, m int
 }
 
 func resourceEntityTagsDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
@@ -2,11 +2,13 @@ package dremio
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	dapi "github.com/saltxwater/go-dremio-api-client"
 )
 
 func resourceEntityWiki() *schema.Resource {
@@ -15,6 +17,7 @@ func resourceEntityWiki() *schema.Resource {
 		ReadContext:   resourceEntityWikiRead,
 		UpdateContext: resourceEntityWikiUpdate,
 		DeleteContext: resourceEntityWikiDelete,
+		CustomizeDiff: resourceEntityWikiCustomizeDiff,
 		Schema: map[string]*schema.Schema{
 			"entity_id": {
 				Type:     schema.TypeString,
@@ -22,24 +25,72 @@ func resourceEntityWiki() *schema.Resource {
 				ForceNew: true,
 			},
 			"text": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"text", "source_file"},
+				Description:  "Wiki text. Sections generated by dremio_dataset_doc and the column blocks of dremio_virtual_dataset are kept in place and are not part of this text.",
+			},
+			"source_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"text", "source_file"},
+			},
+			"content_sha256": {
 				Type:     schema.TypeString,
-				Required: true,
+				Computed: true,
 			},
 		},
 	}
 }
 
+func getEntityWikiContent(text string, sourceFile string) (string, error) {
+	if sourceFile == "" {
+		return text, nil
+	}
+	content, err := ioutil.ReadFile(sourceFile)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func getContentSha256(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// Wikis loaded from source_file are not kept in state, changes on either side
+// are detected by comparing the hash of the file against the hash read back.
+func resourceEntityWikiCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("text") || !d.NewValueKnown("source_file") {
+		return d.SetNewComputed("content_sha256")
+	}
+	content, err := getEntityWikiContent(d.Get("text").(string), d.Get("source_file").(string))
+	if err != nil {
+		return err
+	}
+	sha := getContentSha256(content)
+	if sha != d.Get("content_sha256").(string) {
+		return d.SetNew("content_sha256", sha)
+	}
+	return nil
+}
+
 func resourceEntityWikiCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	entityId := d.Get("entity_id").(string)
-	text := d.Get("text").(string)
+	text, err := getEntityWikiContent(d.Get("text").(string), d.Get("source_file").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	wikiVersion := 0
 	wikiBody, err := c.GetEntityWiki(entityId)
 	if err != nil {
 		// Assume failed because no tags already exist?
 	} else {
 		wikiVersion = wikiBody.Version
+		text = keepWikiSections(text, wikiBody.Text)
 	}
 
 	err = c.SetEntityWiki(entityId, text, wikiVersion)
@@ -54,34 +105,42 @@ func resourceEntityWikiCreate(ctx context.Context, d *schema.ResourceData, m int
 }
 
 func resourceEntityWikiRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	entityId := d.Id()
 
-	text, err := c.GetEntityWiki(entityId)
+	wikiBody, err := c.GetEntityWiki(entityId)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	d.Set("text", text)
+	// Sections generated by other resources are not part of this wiki's text
+	text := stripWikiSections(wikiBody.Text)
+	if d.Get("source_file").(string) == "" {
+		d.Set("text", text)
+	}
+	d.Set("content_sha256", getContentSha256(text))
 
 	return diags
 }
 
 func resourceEntityWikiUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	entityId := d.Id()
-	text := d.Get("text").(string)
+	text, err := getEntityWikiContent(d.Get("text").(string), d.Get("source_file").(string))
+	if err != nil {
+		return diag.FromErr(err)
+	}
 	wikiBody, err := c.GetEntityWiki(entityId)
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	err = c.SetEntityWiki(entityId, text, wikiBody.Version)
+	err = c.SetEntityWiki(entityId, keepWikiSections(text, wikiBody.Text), wikiBody.Version)
 
 	if err != nil {
 		return diag.FromErr(err)
@@ -93,7 +152,7 @@ func resourceEntityWikiUpdate(ctx context.Context, d *schema.ResourceData, m int
 }
 
 func resourceEntityWikiDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
@@ -101,7 +160,7 @@ func resourceEntityWikiDelete(ctx context.Context, d *schema.ResourceData, m int
 	entityId := d.Id()
 	wikiBody, err := c.GetEntityWiki(entityId)
 	if err == nil {
-		err := c.SetEntityWiki(entityId, "", wikiBody.Version)
+		err := c.SetEntityWiki(entityId, keepWikiSections("", wikiBody.Text), wikiBody.Version)
 		if err != nil {
 			return diag.FromErr(err)
 		}
@@ -0,0 +1,149 @@
+package dremio
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func resourceExternalReflection() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceExternalReflectionCreate,
+		ReadContext:   resourceExternalReflectionRead,
+		UpdateContext: resourceExternalReflectionUpdate,
+		DeleteContext: resourceExternalReflectionDelete,
+		CustomizeDiff: resourceExternalReflectionCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"dataset_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Dataset whose queries are accelerated.",
+			},
+			"target_dataset_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Dataset holding the pre-computed data. It must have every field of dataset_id, with the same types.",
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "External",
+			},
+			"enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+		},
+	}
+}
+
+func resourceExternalReflectionCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if !d.NewValueKnown("dataset_id") || !d.NewValueKnown("target_dataset_id") {
+		return nil
+	}
+	err := checkExternalReflectionFields(ctx, m.(*providerMeta).api, d.Get("dataset_id").(string), d.Get("target_dataset_id").(string))
+	if isApiStatus(err, http.StatusNotFound) {
+		// Either dataset may not exist until it is created in this apply
+		log.Printf("Skipping external reflection field validation: %s", err)
+		return nil
+	}
+	return err
+}
+
+func getExternalReflectionSpec(d *schema.ResourceData) *apiReflectionSpec {
+	return &apiReflectionSpec{
+		Type:            "EXTERNAL",
+		Name:            d.Get("name").(string),
+		DatasetId:       d.Get("dataset_id").(string),
+		Enabled:         d.Get("enabled").(bool),
+		TargetDatasetId: d.Get("target_dataset_id").(string),
+	}
+}
+
+func resourceExternalReflectionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	api := m.(*providerMeta).api
+
+	spec := getExternalReflectionSpec(d)
+	if err := checkExternalReflectionFields(ctx, api, spec.DatasetId, spec.TargetDatasetId); err != nil {
+		return diag.FromErr(err)
+	}
+
+	res, err := api.createReflection(ctx, spec)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(res.Id)
+
+	return resourceExternalReflectionRead(ctx, d, m)
+}
+
+func resourceExternalReflectionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	api := m.(*providerMeta).api
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	resId := d.Id()
+
+	res, err := api.getReflection(ctx, resId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.Set("name", res.Name)
+	d.Set("enabled", res.Enabled)
+	if err := d.Set("dataset_id", res.DatasetId); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("target_dataset_id", res.TargetDatasetId); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
+
+func resourceExternalReflectionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	api := m.(*providerMeta).api
+
+	resId := d.Id()
+
+	spec := getExternalReflectionSpec(d)
+	if d.HasChange("target_dataset_id") {
+		if err := checkExternalReflectionFields(ctx, api, spec.DatasetId, spec.TargetDatasetId); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	err := api.updateReflection(ctx, resId, spec)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	d.Set("last_updated", time.Now().Format(time.RFC850))
+
+	return resourceExternalReflectionRead(ctx, d, m)
+}
+
+func resourceExternalReflectionDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	resId := d.Id()
+
+	err := c.DeleteReflection(resId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
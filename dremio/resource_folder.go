@@ -12,8 +12,9 @@ func resourceFolder() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceFolderCreate,
 		ReadContext:   resourceFolderRead,
+		UpdateContext: resourceFolderUpdate,
 		DeleteContext: resourceFolderDelete,
-		Schema: map[string]*schema.Schema{
+		Schema: makeAdoptableSchema(makeContainerSchema(map[string]*schema.Schema{
 			"path": {
 				Type:     schema.TypeList,
 				Required: true,
@@ -22,18 +23,29 @@ func resourceFolder() *schema.Resource {
 					Type: schema.TypeString,
 				},
 			},
-		},
+		})),
 	}
 }
 
 func resourceFolderCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
+	path := interfaceListToStringList(d.Get("path").([]interface{}))
+
+	id, ok, err := findAdoptableEntity(ctx, m.(*providerMeta).api, d, path, catalogTypeFolder)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if ok {
+		d.SetId(id)
+		return resourceFolderRead(ctx, d, m)
+	}
+
 	folder, err := c.NewFolder(&dapi.NewFolderSpec{
-		Path: interfaceListToStringList(d.Get("path").([]interface{})),
+		Path: path,
 	})
 	if err != nil {
 		return diag.FromErr(err)
@@ -47,7 +59,7 @@ func resourceFolderCreate(ctx context.Context, d *schema.ResourceData, m interfa
 }
 
 func resourceFolderRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
@@ -66,18 +78,25 @@ func resourceFolderRead(ctx context.Context, d *schema.ResourceData, m interface
 	return diags
 }
 
-func resourceOrderUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+func resourceFolderUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
 	return resourceFolderRead(ctx, d, m)
 }
 
 func resourceFolderDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	folderId := d.Id()
 
+	if err := checkDeletionProtection(d); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := checkContainerEmpty(c, d); err != nil {
+		return diag.FromErr(err)
+	}
+
 	err := c.DeleteCatalogItem(folderId)
 	if err != nil {
 		return diag.FromErr(err)
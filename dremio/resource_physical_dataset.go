@@ -2,6 +2,7 @@ package dremio
 
 import (
 	"context"
+	"fmt"
 	"log"
 	"time"
 
@@ -16,12 +17,97 @@ func resourcePhysicalDataset() *schema.Resource {
 		ReadContext:   resourcePhysicalDatasetRead,
 		UpdateContext: resourcePhysicalDatasetUpdate,
 		DeleteContext: resourcePhysicalDatasetDelete,
-		Schema:        makePhysicalDatasetSchema(map[string]*schema.Schema{}),
+		CustomizeDiff: resourcePhysicalDatasetCustomizeDiff,
+		Schema: makePhysicalDatasetSchema(map[string]*schema.Schema{
+			"on_destroy": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "restore",
+				ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
+					v := val.(string)
+					if v == "restore" || v == "reset" || v == "unpromote" {
+						return
+					}
+					errs = append(errs, fmt.Errorf("%s must be 'restore', 'reset' or 'unpromote', got: %s", key, v))
+					return
+				},
+			},
+			"original_acceleration_policy": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"refresh_period_ms": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"grace_period_ms": {
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"method": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"refresh_field": {
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"never_expire": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+						"never_refresh": {
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+		}),
+	}
+}
+
+func flattenAccelerationRefreshPolicy(acc *dapi.DatasetAccelerationRefreshPolicy) []interface{} {
+	if acc == nil {
+		return []interface{}{}
+	}
+	return []interface{}{
+		map[string]interface{}{
+			"refresh_period_ms": acc.RefreshPeriodMs,
+			"grace_period_ms":   acc.GracePeriodMs,
+			"method":            acc.Method,
+			"refresh_field":     acc.RefreshField,
+			"never_expire":      acc.NeverExpire,
+			"never_refresh":     acc.NeverRefresh,
+		},
+	}
+}
+
+func resourcePhysicalDatasetCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if err := planInheritedAccelerationPolicy(d); err != nil {
+		return err
+	}
+	return checkExpectedFieldsOnPlan(d)
+}
+
+func expandAccelerationRefreshPolicy(items []interface{}) *dapi.DatasetAccelerationRefreshPolicy {
+	if len(items) == 0 || items[0] == nil {
+		return nil
+	}
+	acc := items[0].(map[string]interface{})
+	return &dapi.DatasetAccelerationRefreshPolicy{
+		RefreshPeriodMs: acc["refresh_period_ms"].(int),
+		GracePeriodMs:   acc["grace_period_ms"].(int),
+		Method:          acc["method"].(string),
+		RefreshField:    acc["refresh_field"].(string),
+		NeverExpire:     acc["never_expire"].(bool),
+		NeverRefresh:    acc["never_refresh"].(bool),
 	}
 }
 
 func resourcePhysicalDatasetCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	absolutePath, err := getPhysicalDatasetAbsolutePath(c, d)
 	if err != nil {
@@ -33,12 +119,23 @@ func resourcePhysicalDatasetCreate(ctx context.Context, d *schema.ResourceData,
 	if err != nil {
 		return diag.FromErr(err)
 	}
+
+	// Keep the policy in place before this resource took over, so it can be
+	// put back on destroy
+	existing, err := c.GetPhysicalDataset(original.Id)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("original_acceleration_policy", flattenAccelerationRefreshPolicy(existing.AccelerationRefreshPolicy)); err != nil {
+		return diag.FromErr(err)
+	}
+
 	d.SetId(original.Id)
 	return resourcePhysicalDatasetUpdate(ctx, d, m)
 }
 
 func resourcePhysicalDatasetRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
@@ -50,42 +147,66 @@ func resourcePhysicalDatasetRead(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(err)
 	}
 
-	if err := readPhysicalDatasetCommon(d, pds); err != nil {
+	if err := readPhysicalDatasetCommon(ctx, m, d, pds); err != nil {
 		return diag.FromErr(err)
 	}
 
-	return diags
+	if err := readCatalogTag(c, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Checked on every refresh, so upstream changes that break the contract
+	// are reported even when this resource itself is unchanged
+	return append(diags, checkExpectedFields(d, diag.Warning)...)
 }
 
 func resourcePhysicalDatasetUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
-
-	sourceId := d.Id()
+	api := m.(*providerMeta).api
 
-	_, err := c.UpdatePhysicalDataset(sourceId, &dapi.UpdatePhysicalDatasetSpec{
-		AccelerationRefreshPolicy: getDatasetAccelerationRefreshPolicy(d),
+	err := updateCatalogEntity(ctx, api, d, func(entity map[string]interface{}) {
+		applyDatasetAccelerationPolicy(d, entity)
 	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	d.Set("last_updated", time.Now().Format(time.RFC850))
 
-	return resourcePhysicalDatasetRead(ctx, d, m)
+	return enforceExpectedFields(d, resourcePhysicalDatasetRead(ctx, d, m))
 }
 
 func resourcePhysicalDatasetDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	pdsId := d.Id()
 
-	_, err := c.UpdatePhysicalDataset(pdsId, &dapi.UpdatePhysicalDatasetSpec{})
-	if err != nil {
+	if err := checkDeletionProtection(d); err != nil {
 		return diag.FromErr(err)
 	}
 
+	switch d.Get("on_destroy").(string) {
+	case "unpromote":
+		if err := c.DeleteCatalogItem(pdsId); err != nil {
+			return diag.FromErr(err)
+		}
+	case "restore":
+		// State from before the snapshot was taken has no policy to restore,
+		// which resets it as before
+		_, err := c.UpdatePhysicalDataset(pdsId, &dapi.UpdatePhysicalDatasetSpec{
+			AccelerationRefreshPolicy: expandAccelerationRefreshPolicy(d.Get("original_acceleration_policy").([]interface{})),
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	default:
+		_, err := c.UpdatePhysicalDataset(pdsId, &dapi.UpdatePhysicalDatasetSpec{})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	d.SetId("")
 
 	return diags
@@ -16,6 +16,7 @@ func resourcePromotedDataset() *schema.Resource {
 		ReadContext:   resourcePromotedDatasetRead,
 		UpdateContext: resourcePromotedDatasetUpdate,
 		DeleteContext: resourcePromotedDatasetDelete,
+		CustomizeDiff: resourcePromotedDatasetCustomizeDiff,
 		Schema: makePhysicalDatasetSchema(map[string]*schema.Schema{
 			"type": {
 				Type:     schema.TypeString,
@@ -69,11 +70,18 @@ func resourcePromotedDataset() *schema.Resource {
 	}
 }
 
-func resourcePromotedDatasetCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+// The format decides how the files are read, so changing it can change the
+// dataset's fields.
+func resourcePromotedDatasetCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if err := planInheritedAccelerationPolicy(d); err != nil {
+		return err
+	}
+	return checkExpectedFieldsOnPlan(d, "type", "field_delimiter", "line_delimiter", "quote", "comment", "escape",
+		"skip_first_line", "extract_header", "trim_header", "auto_generate_column_names", "sheet_name", "has_merged_cells")
+}
 
-	// Warning or errors can be collected in a slice type
-	var diags diag.Diagnostics
+func resourcePromotedDatasetCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
 
 	absolutePath, err := getPhysicalDatasetAbsolutePath(c, d)
 	if err != nil {
@@ -87,9 +95,8 @@ func resourcePromotedDatasetCreate(ctx context.Context, d *schema.ResourceData,
 	}
 	log.Printf("PDS target Id: %s, path: %v", original.Id, original.Path)
 	pds, err := c.NewPhysicalDataset(original.Id, &dapi.NewPhysicalDatasetSpec{
-		Path:                      original.Path,
-		Format:                    getPhysicalDatasetFormat(d),
-		AccelerationRefreshPolicy: getDatasetAccelerationRefreshPolicy(d),
+		Path:   original.Path,
+		Format: getPhysicalDatasetFormat(d),
 	})
 	if err != nil {
 		return diag.FromErr(err)
@@ -97,13 +104,13 @@ func resourcePromotedDatasetCreate(ctx context.Context, d *schema.ResourceData,
 
 	d.SetId(pds.Id)
 
-	resourcePromotedDatasetRead(ctx, d, m)
-
-	return diags
+	// The client cannot send the policy type and schedule, so the policy is
+	// written through the catalog API
+	return resourcePromotedDatasetUpdate(ctx, d, m)
 }
 
 func resourcePromotedDatasetRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
@@ -119,38 +126,47 @@ func resourcePromotedDatasetRead(ctx context.Context, d *schema.ResourceData, m
 		return diag.FromErr(err)
 	}
 
-	if err := readPhysicalDatasetCommon(d, pds); err != nil {
+	if err := readPhysicalDatasetCommon(ctx, m, d, pds); err != nil {
 		return diag.FromErr(err)
 	}
 
-	return diags
+	if err := readCatalogTag(c, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Checked on every refresh, so upstream changes that break the contract
+	// are reported even when this resource itself is unchanged
+	return append(diags, checkExpectedFields(d, diag.Warning)...)
 }
 
 func resourcePromotedDatasetUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
-
-	sourceId := d.Id()
+	api := m.(*providerMeta).api
 
-	_, err := c.UpdatePhysicalDataset(sourceId, &dapi.UpdatePhysicalDatasetSpec{
-		Format:                    getPhysicalDatasetFormat(d),
-		AccelerationRefreshPolicy: getDatasetAccelerationRefreshPolicy(d),
+	err := updateCatalogEntity(ctx, api, d, func(entity map[string]interface{}) {
+		// The format settings depend on its type, so it is replaced as a whole
+		entity["format"] = physicalDatasetFormatToApi(getPhysicalDatasetFormat(d))
+		applyDatasetAccelerationPolicy(d, entity)
 	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	d.Set("last_updated", time.Now().Format(time.RFC850))
 
-	return resourcePromotedDatasetRead(ctx, d, m)
+	return enforceExpectedFields(d, resourcePromotedDatasetRead(ctx, d, m))
 }
 
 func resourcePromotedDatasetDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	pdsId := d.Id()
 
+	if err := checkDeletionProtection(d); err != nil {
+		return diag.FromErr(err)
+	}
+
 	err := c.DeleteCatalogItem(pdsId)
 	if err != nil {
 		return diag.FromErr(err)
@@ -178,6 +194,35 @@ func getPhysicalDatasetFormat(d *schema.ResourceData) *dapi.PhysicalDatasetForma
 	}
 }
 
+// Lists the settings which apply to the format type, as the catalog API
+// expects them.
+func physicalDatasetFormatToApi(format *dapi.PhysicalDatasetFormat) map[string]interface{} {
+	apiFormat := map[string]interface{}{
+		"type": format.Type,
+	}
+	switch format.Type {
+	case "Text":
+		mergeApiObject(apiFormat, map[string]interface{}{
+			"fieldDelimiter":          format.FieldDelimiter,
+			"lineDelimiter":           format.LineDelimiter,
+			"quote":                   format.Quote,
+			"comment":                 format.Comment,
+			"escape":                  format.Escape,
+			"skipFirstLine":           format.SkipFirstLine,
+			"extractHeader":           format.ExtractHeader,
+			"trimHeader":              format.TrimHeader,
+			"autoGenerateColumnNames": format.AutoGenerateColumnNames,
+		})
+	case "Excel", "XLS":
+		mergeApiObject(apiFormat, map[string]interface{}{
+			"sheetName":      format.SheetName,
+			"extractHeader":  format.ExtractHeader,
+			"hasMergedCells": format.HasMergedCells,
+		})
+	}
+	return apiFormat
+}
+
 func readPhysicalDatasetFormat(d *schema.ResourceData, format *dapi.PhysicalDatasetFormat) error {
 	if format == nil {
 		if err := d.Set("type", ""); err != nil {
@@ -6,16 +6,20 @@ import (
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
-	dapi "github.com/saltxwater/go-dremio-api-client"
 )
 
 func resourceRawReflection() *schema.Resource {
-	return &schema.Resource{
+	r := &schema.Resource{
 		CreateContext: resourceRawReflectionCreate,
 		ReadContext:   resourceRawReflectionRead,
 		UpdateContext: resourceRawReflectionUpdate,
 		DeleteContext: resourceRawReflectionDelete,
-		Schema: map[string]*schema.Schema{
+		CustomizeDiff: resourceRawReflectionCustomizeDiff,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+			Update: schema.DefaultTimeout(30 * time.Minute),
+		},
+		Schema: makeReflectionStatusSchema(makeReflectionPartitionSchema(map[string]*schema.Schema{
 			"dataset_id": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -39,14 +43,7 @@ func resourceRawReflection() *schema.Resource {
 				Default:  true,
 			},
 			"distribution_fields": {
-				Type:     schema.TypeList,
-				Optional: true,
-				Elem: &schema.Schema{
-					Type: schema.TypeString,
-				},
-			},
-			"partition_fields": {
-				Type:     schema.TypeList,
+				Type:     schema.TypeSet,
 				Optional: true,
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
@@ -64,11 +61,20 @@ func resourceRawReflection() *schema.Resource {
 				Optional: true,
 				Default:  "CONSOLIDATED",
 			},
+		})),
+	}
+	r.SchemaVersion = 1
+	r.StateUpgraders = []schema.StateUpgrader{
+		{
+			Version: 0,
+			Type:    resourceRawReflectionV0().CoreConfigSchema().ImpliedType(),
+			Upgrade: upgradeReflectionStateV0,
 		},
 	}
+	return r
 }
 
-func reflectionFieldListToStringList(itemsRaw []dapi.ReflectionField) []string {
+func reflectionFieldListToStringList(itemsRaw []apiReflectionField) []string {
 	items := make([]string, len(itemsRaw))
 	for i, raw := range itemsRaw {
 		items[i] = raw.Name
@@ -76,60 +82,76 @@ func reflectionFieldListToStringList(itemsRaw []dapi.ReflectionField) []string {
 	return items
 }
 
-func interfaceListToReflectionFieldList(itemsRaw []interface{}) []dapi.ReflectionField {
-	items := make([]dapi.ReflectionField, len(itemsRaw))
+func interfaceListToReflectionFieldList(itemsRaw []interface{}) []apiReflectionField {
+	items := make([]apiReflectionField, len(itemsRaw))
 	for i, raw := range itemsRaw {
-		items[i] = dapi.ReflectionField{
+		items[i] = apiReflectionField{
 			Name: raw.(string),
 		}
 	}
 	return items
 }
 
+func resourceRawReflectionCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	return validateRawReflectionFields(ctx, m.(*providerMeta).api, d)
+}
+
+func validateRawReflectionFields(ctx context.Context, api *apiConnection, d reflectionConfig) error {
+	refs := collectReflectionFieldRefs(d, "display_fields", "distribution_fields", "partition_fields", "sort_fields")
+	partitionRefs, err := collectPartitionFieldRefs(d)
+	if err != nil {
+		return err
+	}
+	return validateReflectionFields(ctx, api, d, append(refs, partitionRefs...))
+}
+
+func getRawReflectionSpec(d *schema.ResourceData) *apiReflectionSpec {
+	return &apiReflectionSpec{
+		Type:                          "RAW",
+		Name:                          d.Get("name").(string),
+		DatasetId:                     d.Get("dataset_id").(string),
+		Enabled:                       d.Get("enabled").(bool),
+		DisplayFields:                 interfaceListToReflectionFieldList(d.Get("display_fields").([]interface{})),
+		DistributionFields:            interfaceListToReflectionFieldList(d.Get("distribution_fields").(*schema.Set).List()),
+		PartitionFields:               getPartitionFields(d),
+		SortFields:                    interfaceListToReflectionFieldList(d.Get("sort_fields").([]interface{})),
+		PartitionDistributionStrategy: d.Get("partition_distribution_strategy").(string),
+	}
+}
+
 func resourceRawReflectionCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	api := m.(*providerMeta).api
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
-	datasetId := d.Get("dataset_id").(string)
-	name := d.Get("name").(string)
-	enabled := d.Get("enabled").(bool)
-	displayFields := interfaceListToReflectionFieldList(d.Get("display_fields").([]interface{}))
-	distributionFields := interfaceListToReflectionFieldList(d.Get("distribution_fields").([]interface{}))
-	partitionFields := interfaceListToReflectionFieldList(d.Get("partition_fields").([]interface{}))
-	sortFields := interfaceListToReflectionFieldList(d.Get("sort_fields").([]interface{}))
-	partitionDistributionStrategy := d.Get("partition_distribution_strategy").(string)
-
-	res, err := c.NewRawReflection(datasetId, &dapi.RawReflectionSpec{
-		Name:                          name,
-		Enabled:                       enabled,
-		DisplayFields:                 displayFields,
-		DistributionFields:            distributionFields,
-		PartitionFields:               partitionFields,
-		SortFields:                    sortFields,
-		PartitionDistributionStrategy: partitionDistributionStrategy,
-	})
+	if err := validateRawReflectionFields(ctx, api, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	res, err := api.createReflection(ctx, getRawReflectionSpec(d))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
 	d.SetId(res.Id)
 
-	resourceRawReflectionRead(ctx, d, m)
+	if err := waitForReflectionMaterialization(ctx, api, d, d.Timeout(schema.TimeoutCreate)); err != nil {
+		return append(diags, diag.FromErr(err)...)
+	}
 
-	return diags
+	return append(diags, resourceRawReflectionRead(ctx, d, m)...)
 }
 
 func resourceRawReflectionRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	api := m.(*providerMeta).api
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	resId := d.Id()
 
-	res, err := c.GetRawReflection(resId)
+	res, err := api.getReflection(ctx, resId)
 	if err != nil {
 		return diag.FromErr(err)
 	}
@@ -142,49 +164,44 @@ func resourceRawReflectionRead(ctx context.Context, d *schema.ResourceData, m in
 	if err := d.Set("distribution_fields", reflectionFieldListToStringList(res.DistributionFields)); err != nil {
 		return diag.FromErr(err)
 	}
-	if err := d.Set("partition_fields", reflectionFieldListToStringList(res.PartitionFields)); err != nil {
+	if err := setPartitionFields(d, res.PartitionFields); err != nil {
 		return diag.FromErr(err)
 	}
 	if err := d.Set("sort_fields", reflectionFieldListToStringList(res.SortFields)); err != nil {
 		return diag.FromErr(err)
 	}
 	d.Set("partition_distribution_strategy", res.PartitionDistributionStrategy)
+	if err := readReflectionStatus(d, res); err != nil {
+		return diag.FromErr(err)
+	}
 
 	return diags
 }
 
 func resourceRawReflectionUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	api := m.(*providerMeta).api
 
 	resId := d.Id()
 
-	name := d.Get("name").(string)
-	enabled := d.Get("enabled").(bool)
-	displayFields := interfaceListToReflectionFieldList(d.Get("display_fields").([]interface{}))
-	distributionFields := interfaceListToReflectionFieldList(d.Get("distribution_fields").([]interface{}))
-	partitionFields := interfaceListToReflectionFieldList(d.Get("partition_fields").([]interface{}))
-	sortFields := interfaceListToReflectionFieldList(d.Get("sort_fields").([]interface{}))
-	partitionDistributionStrategy := d.Get("partition_distribution_strategy").(string)
-
-	_, err := c.UpdateRawReflection(resId, &dapi.RawReflectionSpec{
-		Name:                          name,
-		Enabled:                       enabled,
-		DisplayFields:                 displayFields,
-		DistributionFields:            distributionFields,
-		PartitionFields:               partitionFields,
-		SortFields:                    sortFields,
-		PartitionDistributionStrategy: partitionDistributionStrategy,
-	})
+	if err := validateRawReflectionFields(ctx, api, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	err := api.updateReflection(ctx, resId, getRawReflectionSpec(d))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 	d.Set("last_updated", time.Now().Format(time.RFC850))
 
+	if err := waitForReflectionMaterialization(ctx, api, d, d.Timeout(schema.TimeoutUpdate)); err != nil {
+		return diag.FromErr(err)
+	}
+
 	return resourceRawReflectionRead(ctx, d, m)
 }
 
 func resourceRawReflectionDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
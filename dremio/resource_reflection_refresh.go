@@ -0,0 +1,154 @@
+package dremio
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+const reflectionRefreshPollInterval = 10 * time.Second
+
+func resourceReflectionRefresh() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceReflectionRefreshCreate,
+		ReadContext:   resourceReflectionRefreshRead,
+		UpdateContext: resourceReflectionRefreshUpdate,
+		DeleteContext: resourceReflectionRefreshDelete,
+		Timeouts: &schema.ResourceTimeout{
+			Create: schema.DefaultTimeout(30 * time.Minute),
+		},
+		Schema: map[string]*schema.Schema{
+			"dataset_id": {
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Dataset whose dependent reflections are refreshed.",
+			},
+			"triggers": {
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Arbitrary values that trigger another refresh whenever they change.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"wait_for_completion": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: "Wait until the enabled reflections defined on the dataset itself have fetched new data. " +
+					"Reflections on datasets built on top of it are refreshed too but not waited for.",
+			},
+			"refreshed_at": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "When the refresh was triggered.",
+			},
+		},
+	}
+}
+
+func resourceReflectionRefreshCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	api := m.(*providerMeta).api
+
+	datasetId := d.Get("dataset_id").(string)
+	wait := d.Get("wait_for_completion").(bool)
+
+	// Taken before the refresh so completion does not depend on the clocks
+	// of Dremio and Terraform agreeing
+	var before []apiReflection
+	if wait {
+		var err error
+		before, err = api.getDatasetReflections(ctx, datasetId)
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if err := api.refreshDatasetReflections(ctx, datasetId); err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(datasetId)
+	d.Set("refreshed_at", time.Now().Format(time.RFC3339))
+
+	if wait {
+		if err := waitForReflectionRefresh(ctx, api, datasetId, before, d.Timeout(schema.TimeoutCreate)); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	return resourceReflectionRefreshRead(ctx, d, m)
+}
+
+func waitForReflectionRefresh(ctx context.Context, api *apiConnection, datasetId string, before []apiReflection, timeout time.Duration) error {
+	lastDataFetch := make(map[string]string, len(before))
+	for _, r := range before {
+		if r.Enabled {
+			lastDataFetch[r.Id] = r.Status.LastDataFetch
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		reflections, err := api.getDatasetReflections(ctx, datasetId)
+		if err != nil {
+			return err
+		}
+		pending := make([]string, 0)
+		for _, r := range reflections {
+			previous, ok := lastDataFetch[r.Id]
+			if !ok || !r.Enabled {
+				continue
+			}
+			if r.Status.CombinedStatus == "FAILED" || r.Status.CombinedStatus == "INVALID" || r.Status.Refresh == "GIVEN_UP" {
+				return fmt.Errorf("reflection '%s' (%s) did not refresh: status %s, refresh %s",
+					r.Name, r.Id, r.Status.CombinedStatus, r.Status.Refresh)
+			}
+			if r.Status.LastDataFetch == previous {
+				pending = append(pending, fmt.Sprintf("'%s' (%s)", r.Name, r.Id))
+			}
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %s waiting for reflections to refresh: %s", timeout, strings.Join(pending, ", "))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(reflectionRefreshPollInterval):
+		}
+	}
+}
+
+func resourceReflectionRefreshRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	// A refresh is a one off action, there is nothing in Dremio to read back
+
+	return diags
+}
+
+func resourceReflectionRefreshUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Only wait_for_completion can change in place, which only matters when
+	// the next refresh is triggered
+	return resourceReflectionRefreshRead(ctx, d, m)
+}
+
+func resourceReflectionRefreshDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	d.SetId("")
+
+	return diags
+}
@@ -16,7 +16,7 @@ func resourceSource() *schema.Resource {
 		ReadContext:   resourceSourceRead,
 		UpdateContext: resourceSourceUpdate,
 		DeleteContext: resourceSourceDelete,
-		Schema: map[string]*schema.Schema{
+		Schema: makeAdoptableSchema(makeSourceContainerSchema(makeAccelerationScheduleSchema(map[string]*schema.Schema{
 			"type": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -39,6 +39,11 @@ func resourceSource() *schema.Resource {
 					Type: schema.TypeString,
 				},
 			},
+			"tag": {
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: catalogTagDescription,
+			},
 			"auth_ttl_ms": {
 				Type:     schema.TypeInt,
 				Optional: true,
@@ -138,10 +143,19 @@ func resourceSource() *schema.Resource {
 					},
 				},
 			},
-		},
+		}))),
 	}
 }
 
+// The children of a source are the folders and tables of the system it
+// connects to, so destroying it loses only what Dremio holds about them.
+func makeSourceContainerSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
+	s = makeContainerSchema(s)
+	s["force_destroy"].Description = "Destroy the source even if it still has folders or tables not managed by this configuration. " +
+		"Their data in the underlying system is not deleted, but their Dremio metadata is lost: formats, reflections, wikis and tags."
+	return s
+}
+
 func getSourceMetadataPolicy(d *schema.ResourceData) *dapi.SourceMetadataPolicy {
 	return &dapi.SourceMetadataPolicy{
 		AuthTTLMs:             d.Get("auth_ttl_ms").(int),
@@ -153,7 +167,16 @@ func getSourceMetadataPolicy(d *schema.ResourceData) *dapi.SourceMetadataPolicy
 }
 
 func resourceSourceCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
+
+	id, ok, err := findAdoptableEntity(ctx, m.(*providerMeta).api, d, []string{d.Get("name").(string)}, catalogTypeSource)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if ok {
+		d.SetId(id)
+		return resourceSourceUpdate(ctx, d, m)
+	}
 
 	config, err := getSourceConfig(d)
 	if err != nil {
@@ -176,11 +199,21 @@ func resourceSourceCreate(ctx context.Context, d *schema.ResourceData, m interfa
 
 	d.SetId(space.Id)
 
+	// The client cannot send the policy type and schedule
+	if schedule := accelerationScheduleToApi(d, "accelerationActivePolicyType", "accelerationRefreshSchedule"); len(schedule) > 0 {
+		err := updateCatalogEntity(ctx, m.(*providerMeta).api, d, func(entity map[string]interface{}) {
+			mergeApiObject(entity, schedule)
+		})
+		if err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
 	return resourceSourceRead(ctx, d, m)
 }
 
 func resourceSourceRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	sourceId := d.Id()
 
@@ -239,26 +272,44 @@ func resourceSourceRead(ctx context.Context, d *schema.ResourceData, m interface
 	if err := d.Set("acc_never_refresh", source.AccelerationNeverRefresh); err != nil {
 		return diag.FromErr(err)
 	}
+	acc, err := m.(*providerMeta).api.getSourceAccelerationPolicy(ctx, sourceId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := readAccelerationSchedule(d, acc.ActivePolicyType, acc.RefreshSchedule); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := readCatalogTag(c, d); err != nil {
+		return diag.FromErr(err)
+	}
 	return diag.Diagnostics{}
 }
 
 func resourceSourceUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
-
-	sourceId := d.Id()
+	api := m.(*providerMeta).api
 
 	config, err := getSourceConfig(d)
 	if err != nil {
 		return diag.FromErr(err)
 	}
-	_, err = c.UpdateSource(sourceId, &dapi.UpdateSourceSpec{
-		Description:                 d.Get("description").(string),
-		Config:                      config,
-		MetadataPolicy:              getSourceMetadataPolicy(d),
-		AccelerationRefreshPeriodMs: d.Get("acc_refresh_period_ms").(int),
-		AccelerationGracePeriodMs:   d.Get("acc_grace_period_ms").(int),
-		AccelerationNeverExpire:     d.Get("acc_never_expire").(bool),
-		AccelerationNeverRefresh:    d.Get("acc_never_refresh").(bool),
+	policy := getSourceMetadataPolicy(d)
+	err = updateCatalogEntity(ctx, api, d, func(entity map[string]interface{}) {
+		mergeApiObject(entity, map[string]interface{}{
+			"description": d.Get("description").(string),
+			"config":      config,
+			"metadataPolicy": map[string]interface{}{
+				"authTTLMs":             policy.AuthTTLMs,
+				"datasetRefreshAfterMs": policy.DatasetRefreshAfterMs,
+				"datasetExpireAfterMs":  policy.DatasetExpireAfterMs,
+				"namesRefreshMs":        policy.NamesRefreshMs,
+				"datasetUpdateMode":     policy.DatasetUpdateMode,
+			},
+			"accelerationRefreshPeriodMs": d.Get("acc_refresh_period_ms").(int),
+			"accelerationGracePeriodMs":   d.Get("acc_grace_period_ms").(int),
+			"accelerationNeverExpire":     d.Get("acc_never_expire").(bool),
+			"accelerationNeverRefresh":    d.Get("acc_never_refresh").(bool),
+		})
+		mergeApiObject(entity, accelerationScheduleToApi(d, "accelerationActivePolicyType", "accelerationRefreshSchedule"))
 	})
 	if err != nil {
 		return diag.FromErr(err)
@@ -269,13 +320,20 @@ func resourceSourceUpdate(ctx context.Context, d *schema.ResourceData, m interfa
 }
 
 func resourceSourceDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	sourceId := d.Id()
 
+	if err := checkDeletionProtection(d); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := checkContainerEmpty(c, d); err != nil {
+		return diag.FromErr(err)
+	}
+
 	err := c.DeleteCatalogItem(sourceId)
 	if err != nil {
 		return diag.FromErr(err)
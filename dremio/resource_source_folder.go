@@ -0,0 +1,121 @@
+package dremio
+
+import (
+	"context"
+	"log"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	dapi "github.com/saltxwater/go-dremio-api-client"
+)
+
+func resourceSourceFolder() *schema.Resource {
+	return &schema.Resource{
+		CreateContext: resourceSourceFolderCreate,
+		ReadContext:   resourceSourceFolderRead,
+		UpdateContext: resourceSourceFolderUpdate,
+		DeleteContext: resourceSourceFolderDelete,
+		Schema: makeAdoptableSchema(makeContainerSchema(map[string]*schema.Schema{
+			"source_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"relative_path": {
+				Type:     schema.TypeList,
+				Required: true,
+				ForceNew: true,
+				MinItems: 1,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"path": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		})),
+	}
+}
+
+func resourceSourceFolderCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	absolutePath, err := getAbsolutePath(c, d.Get("source_id").(string), d.Get("relative_path").([]interface{}))
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	id, ok, err := findAdoptableEntity(ctx, m.(*providerMeta).api, d, absolutePath, catalogTypeFolder)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if ok {
+		d.SetId(id)
+		return resourceSourceFolderRead(ctx, d, m)
+	}
+
+	log.Printf("Creating source folder: %v", absolutePath)
+	folder, err := c.NewFolder(&dapi.NewFolderSpec{
+		Path: absolutePath,
+	})
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId(folder.Id)
+
+	return resourceSourceFolderRead(ctx, d, m)
+}
+
+func resourceSourceFolderRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	folderId := d.Id()
+
+	folder, err := c.GetFolder(folderId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := d.Set("path", folder.Path); err != nil {
+		return diag.FromErr(err)
+	}
+
+	return diags
+}
+
+func resourceSourceFolderUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	return resourceSourceFolderRead(ctx, d, m)
+}
+
+func resourceSourceFolderDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	// Warning or errors can be collected in a slice type
+	var diags diag.Diagnostics
+
+	folderId := d.Id()
+
+	if err := checkDeletionProtection(d); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := checkContainerEmpty(c, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	err := c.DeleteCatalogItem(folderId)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+
+	d.SetId("")
+
+	return diags
+}
@@ -12,8 +12,9 @@ func resourceSpace() *schema.Resource {
 	return &schema.Resource{
 		CreateContext: resourceSpaceCreate,
 		ReadContext:   resourceSpaceRead,
+		UpdateContext: resourceSpaceUpdate,
 		DeleteContext: resourceSpaceDelete,
-		Schema: map[string]*schema.Schema{
+		Schema: makeAdoptableSchema(makeContainerSchema(map[string]*schema.Schema{
 			"name": {
 				Type:     schema.TypeString,
 				Required: true,
@@ -26,16 +27,25 @@ func resourceSpace() *schema.Resource {
 					Type: schema.TypeString,
 				},
 			},
-		},
+		})),
 	}
 }
 
 func resourceSpaceCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
+	id, ok, err := findAdoptableEntity(ctx, m.(*providerMeta).api, d, []string{d.Get("name").(string)}, catalogTypeSpace)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if ok {
+		d.SetId(id)
+		return resourceSpaceRead(ctx, d, m)
+	}
+
 	space, err := c.NewSpace(&dapi.NewSpaceSpec{
 		Name: d.Get("name").(string),
 	})
@@ -51,7 +61,7 @@ func resourceSpaceCreate(ctx context.Context, d *schema.ResourceData, m interfac
 }
 
 func resourceSpaceRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
@@ -73,14 +83,25 @@ func resourceSpaceRead(ctx context.Context, d *schema.ResourceData, m interface{
 	return diags
 }
 
+func resourceSpaceUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	return resourceSpaceRead(ctx, d, m)
+}
+
 func resourceSpaceDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	spaceId := d.Id()
 
+	if err := checkDeletionProtection(d); err != nil {
+		return diag.FromErr(err)
+	}
+	if err := checkContainerEmpty(c, d); err != nil {
+		return diag.FromErr(err)
+	}
+
 	err := c.DeleteCatalogItem(spaceId)
 	if err != nil {
 		return diag.FromErr(err)
@@ -2,6 +2,9 @@ package dremio
 
 import (
 	"context"
+	"fmt"
+	"log"
+	"net/http"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
@@ -15,20 +18,79 @@ func resourceVirtualDataset() *schema.Resource {
 		ReadContext:   resourceVirtualDatasetRead,
 		UpdateContext: resourceVirtualDatasetUpdate,
 		DeleteContext: resourceVirtualDatasetDelete,
-		Schema: makeDatasetSchema(map[string]*schema.Schema{
+		CustomizeDiff: resourceVirtualDatasetCustomizeDiff,
+		Schema: makeAdoptableSchema(makeDatasetSchema(map[string]*schema.Schema{
 			"parent_id": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Id of the space or folder holding the view. Changing it moves the view, keeping its id, reflections, wiki and tags.",
 			},
 			"name": {
-				Type:     schema.TypeString,
-				Required: true,
-				ForceNew: true,
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Changing it renames the view, keeping its id, reflections, wiki and tags.",
 			},
 			"sql": {
+				Type:             schema.TypeString,
+				Optional:         true,
+				Computed:         true,
+				ExactlyOneOf:     []string{"sql", "sql_file"},
+				DiffSuppressFunc: suppressEquivalentSqlDiff,
+			},
+			"sql_file": {
+				Type:         schema.TypeString,
+				Optional:     true,
+				ExactlyOneOf: []string{"sql", "sql_file"},
+			},
+			"sql_vars": {
+				Type:     schema.TypeMap,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"sql_normalized": {
 				Type:     schema.TypeString,
-				Required: true,
+				Computed: true,
+			},
+			"referenced_paths": {
+				Type:     schema.TypeList,
+				Computed: true,
+				Description: "Datasets the SQL reads, in the order they appear in it. Taken from Dremio's lineage graph, " +
+					"or from parsing the SQL locally when the graph is not available.",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"column": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": {
+							Type:     schema.TypeString,
+							Required: true,
+						},
+						"description": {
+							Type:     schema.TypeString,
+							Optional: true,
+						},
+						"tags": {
+							Type:     schema.TypeList,
+							Optional: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeString,
+							},
+						},
+					},
+				},
+			},
+			"validate_sql_on_plan": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  false,
+				Description: "Check the SQL while planning by having Dremio plan it, which reports syntax errors and unknown tables and columns. " +
+					"When the SQL reads a dataset that does not exist yet, for example one created in the same apply, only the syntax is checked, locally.",
 			},
 			"sql_context": {
 				Type:     schema.TypeList,
@@ -38,22 +100,115 @@ func resourceVirtualDataset() *schema.Resource {
 				},
 			},
 		},
-		),
+		)),
 	}
 }
 
-func resourceVirtualDatasetCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+func resourceVirtualDatasetCustomizeDiff(ctx context.Context, d *schema.ResourceDiff, m interface{}) error {
+	if err := renderVirtualDatasetSqlFile(d); err != nil {
+		return err
+	}
+	if err := planVirtualDatasetMove(d, m.(*providerMeta).client); err != nil {
+		return err
+	}
+	if err := checkExpectedFieldsOnPlan(d, "sql", "sql_context"); err != nil {
+		return err
+	}
+	if !d.Get("validate_sql_on_plan").(bool) || !d.NewValueKnown("sql") {
+		return nil
+	}
+	if !d.NewValueKnown("sql_context") {
+		return validateSql(d.Get("sql").(string))
+	}
+	return validateSqlOnServer(ctx, m.(*providerMeta).api, d.Get("sql").(string), interfaceListToStringList(d.Get("sql_context").([]interface{})))
+}
 
-	// Warning or errors can be collected in a slice type
-	var diags diag.Diagnostics
+// A move keeps the entity, so only the path and the version tag change.
+func planVirtualDatasetMove(d *schema.ResourceDiff, c *dapi.Client) error {
+	if d.Id() == "" || !d.HasChanges("parent_id", "name") {
+		return nil
+	}
+	if err := d.SetNewComputed("tag"); err != nil {
+		return err
+	}
+	if !d.NewValueKnown("parent_id") || !d.NewValueKnown("name") {
+		if err := d.SetNewComputed("path"); err != nil {
+			return err
+		}
+		return d.SetNewComputed("query_path")
+	}
+	path, err := getVirtualDatasetPath(c, d.Get("parent_id").(string), d.Get("name").(string))
+	if err != nil {
+		return err
+	}
+	if err := d.SetNew("path", path); err != nil {
+		return err
+	}
+	return d.SetNew("query_path", getQueryPath(path))
+}
 
-	parent, err := c.GetCatalogEntityById(d.Get("parent_id").(string))
+func getVirtualDatasetPath(c *dapi.Client, parentId string, name string) ([]string, error) {
+	parent, err := c.GetCatalogEntityById(parentId)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]string{}, parent.Path...), name), nil
+}
+
+// Dremio keeps the entity when the path of a view is changed through the
+// catalog API. Check that it did, as recreating it would lose the view's
+// reflections and leave resources referring to the old id.
+func checkVirtualDatasetMoved(ctx context.Context, api *apiConnection, d *schema.ResourceData, path []string) error {
+	entity, err := api.getCatalogEntityByPath(ctx, path)
+	if isApiStatus(err, http.StatusNotFound) {
+		return fmt.Errorf("virtual dataset '%s' was not moved to %s", d.Id(), getQueryPath(path))
+	}
+	if err != nil {
+		return err
+	}
+	if entity.Id != d.Id() {
+		return fmt.Errorf("moving virtual dataset '%s' to %s replaced it with '%s' rather than keeping it", d.Id(), getQueryPath(path), entity.Id)
+	}
+	return nil
+}
+
+// The rendered template is planned as the new value of sql, so diffs are
+// tracked against the SQL actually stored in Dremio.
+func renderVirtualDatasetSqlFile(d *schema.ResourceDiff) error {
+	sqlFile := d.Get("sql_file").(string)
+	if sqlFile == "" && d.NewValueKnown("sql_file") {
+		return nil
+	}
+	if !d.NewValueKnown("sql_file") || !d.NewValueKnown("sql_vars") {
+		return d.SetNewComputed("sql")
+	}
+	sql, err := renderSqlFile(sqlFile, d.Get("sql_vars").(map[string]interface{}))
+	if err != nil {
+		return fmt.Errorf("failed to render sql_file: %s", err)
+	}
+	old, _ := d.GetChange("sql")
+	if normalizeSql(old.(string)) == normalizeSql(sql) {
+		return nil
+	}
+	return d.SetNew("sql", sql)
+}
+
+func resourceVirtualDatasetCreate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
+	c := m.(*providerMeta).client
+
+	inputPath, err := getVirtualDatasetPath(c, d.Get("parent_id").(string), d.Get("name").(string))
 	if err != nil {
 		return diag.FromErr(err)
 	}
 
-	inputPath := append(parent.Path, d.Get("name").(string))
+	id, ok, err := findAdoptableEntity(ctx, m.(*providerMeta).api, d, inputPath, catalogTypeVirtualDataset)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if ok {
+		d.SetId(id)
+		return resourceVirtualDatasetUpdate(ctx, d, m)
+	}
 
 	inputSCtx := d.Get("sql_context").([]interface{})
 	sCtx := make([]string, len(inputSCtx))
@@ -72,13 +227,15 @@ func resourceVirtualDatasetCreate(ctx context.Context, d *schema.ResourceData, m
 
 	d.SetId(vds.Id)
 
-	resourceVirtualDatasetRead(ctx, d, m)
+	if err := updateColumnsWiki(c, d); err != nil {
+		return diag.FromErr(err)
+	}
 
-	return diags
+	return enforceExpectedFields(d, resourceVirtualDatasetRead(ctx, d, m))
 }
 
 func resourceVirtualDatasetRead(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
@@ -94,21 +251,42 @@ func resourceVirtualDatasetRead(ctx context.Context, d *schema.ResourceData, m i
 		return diag.FromErr(err)
 	}
 
+	if err := d.Set("sql_normalized", normalizeSql(vds.Sql)); err != nil {
+		return diag.FromErr(err)
+	}
+
+	referencedPaths, err := readReferencedPaths(ctx, m.(*providerMeta).api, vdsId, vds.Sql, vds.SqlContext)
+	if err != nil {
+		return diag.FromErr(err)
+	}
+	if err := d.Set("referenced_paths", referencedPaths); err != nil {
+		return diag.FromErr(err)
+	}
+
 	if err := d.Set("sql_context", vds.SqlContext); err != nil {
 		return diag.FromErr(err)
 	}
 
-	if err := readDatasetCommon(d, &vds.Dataset); err != nil {
+	if err := readDatasetCommon(ctx, m.(*providerMeta).api, d, &vds.Dataset); err != nil {
 		return diag.FromErr(err)
 	}
 
-	return diags
+	if err := readCatalogTag(c, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	if err := readColumnsWiki(c, d); err != nil {
+		return diag.FromErr(err)
+	}
+
+	// Checked on every refresh, so upstream changes that break the contract
+	// are reported even when this resource itself is unchanged
+	return append(diags, checkExpectedFields(d, diag.Warning)...)
 }
 
 func resourceVirtualDatasetUpdate(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
-
-	sourceId := d.Id()
+	c := m.(*providerMeta).client
+	api := m.(*providerMeta).api
 
 	inputSCtx := d.Get("sql_context").([]interface{})
 	sCtx := make([]string, len(inputSCtx))
@@ -116,26 +294,55 @@ func resourceVirtualDatasetUpdate(ctx context.Context, d *schema.ResourceData, m
 		sCtx[i] = elem.(string)
 	}
 
-	_, err := c.UpdateVirtualDataset(sourceId, &dapi.UpdateVirtualDatasetSpec{
-		Sql:        d.Get("sql").(string),
-		SqlContext: sCtx,
+	var path []string
+	move := !d.IsNewResource() && d.HasChanges("parent_id", "name")
+	if move {
+		var err error
+		path, err = getVirtualDatasetPath(c, d.Get("parent_id").(string), d.Get("name").(string))
+		if err != nil {
+			return diag.FromErr(err)
+		}
+		log.Printf("Moving virtual dataset '%s' to %v", d.Id(), path)
+	}
+
+	err := updateCatalogEntity(ctx, api, d, func(entity map[string]interface{}) {
+		entity["sql"] = d.Get("sql").(string)
+		entity["sqlContext"] = sCtx
+		if move {
+			entity["path"] = path
+		}
 	})
 	if err != nil {
 		return diag.FromErr(err)
 	}
+	if move {
+		if err := checkVirtualDatasetMoved(ctx, api, d, path); err != nil {
+			return diag.FromErr(err)
+		}
+	}
+
+	if d.HasChange("column") {
+		if err := updateColumnsWiki(c, d); err != nil {
+			return diag.FromErr(err)
+		}
+	}
 	d.Set("last_updated", time.Now().Format(time.RFC850))
 
-	return resourceVirtualDatasetRead(ctx, d, m)
+	return enforceExpectedFields(d, resourceVirtualDatasetRead(ctx, d, m))
 }
 
 func resourceVirtualDatasetDelete(ctx context.Context, d *schema.ResourceData, m interface{}) diag.Diagnostics {
-	c := m.(*dapi.Client)
+	c := m.(*providerMeta).client
 
 	// Warning or errors can be collected in a slice type
 	var diags diag.Diagnostics
 
 	vdsId := d.Id()
 
+	if err := checkDeletionProtection(d); err != nil {
+		return diag.FromErr(err)
+	}
+
 	err := c.DeleteCatalogItem(vdsId)
 	if err != nil {
 		return diag.FromErr(err)
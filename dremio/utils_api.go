@@ -0,0 +1,440 @@
+package dremio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	dapi "github.com/saltxwater/go-dremio-api-client"
+)
+
+// Calls REST endpoints that the client does not wrap, authenticating the same
+// way with the provider's api_key or username and password.
+type apiConnection struct {
+	baseUrl string
+	config  dapi.Config
+	http    *http.Client
+
+	mu            sync.Mutex
+	authorization string
+}
+
+type apiCatalogEntity struct {
+	Id         string   `json:"id"`
+	EntityType string   `json:"entityType"`
+	Type       string   `json:"type"`
+	Path       []string `json:"path"`
+	Tag        string   `json:"tag"`
+}
+
+// Returns the dataset type for datasets and the entity type otherwise.
+func (e *apiCatalogEntity) kind() string {
+	if e.EntityType == catalogTypeDataset {
+		return e.Type
+	}
+	return e.EntityType
+}
+
+// The acceleration refresh policy of a dataset. Sources hold the same settings
+// as top level keys with an acceleration prefix.
+type apiAccelerationRefreshPolicy struct {
+	RefreshPeriodMs  int    `json:"refreshPeriodMs"`
+	GracePeriodMs    int    `json:"gracePeriodMs"`
+	Method           string `json:"method"`
+	RefreshField     string `json:"refreshField"`
+	NeverExpire      bool   `json:"neverExpire"`
+	NeverRefresh     bool   `json:"neverRefresh"`
+	ActivePolicyType string `json:"activePolicyType"`
+	RefreshSchedule  string `json:"refreshSchedule"`
+}
+
+type apiReflectionStatus struct {
+	Config         string `json:"config"`
+	Refresh        string `json:"refresh"`
+	Availability   string `json:"availability"`
+	CombinedStatus string `json:"combinedStatus"`
+	LastDataFetch  string `json:"lastDataFetch"`
+	ExpiresAt      string `json:"expiresAt"`
+}
+
+type apiReflectionField struct {
+	Name string `json:"name"`
+}
+
+type apiReflectionDimensionField struct {
+	Name        string `json:"name"`
+	Granularity string `json:"granularity,omitempty"`
+}
+
+type apiReflectionMeasureField struct {
+	Name            string   `json:"name"`
+	MeasureTypeList []string `json:"measureTypeList"`
+}
+
+type apiBucketTransform struct {
+	BucketCount int `json:"bucketCount"`
+}
+
+type apiTruncateTransform struct {
+	TruncateLength int `json:"truncateLength"`
+}
+
+type apiPartitionTransform struct {
+	Type              string                `json:"type"`
+	BucketTransform   *apiBucketTransform   `json:"bucketTransform,omitempty"`
+	TruncateTransform *apiTruncateTransform `json:"truncateTransform,omitempty"`
+}
+
+type apiReflectionPartitionField struct {
+	Name      string                 `json:"name"`
+	Transform *apiPartitionTransform `json:"transform,omitempty"`
+}
+
+// The settings sent when creating or updating a reflection.
+type apiReflectionSpec struct {
+	Type                          string                        `json:"type"`
+	Name                          string                        `json:"name"`
+	DatasetId                     string                        `json:"datasetId"`
+	Enabled                       bool                          `json:"enabled"`
+	DisplayFields                 []apiReflectionField          `json:"displayFields,omitempty"`
+	DimensionFields               []apiReflectionDimensionField `json:"dimensionFields,omitempty"`
+	MeasureFields                 []apiReflectionMeasureField   `json:"measureFields,omitempty"`
+	DistributionFields            []apiReflectionField          `json:"distributionFields,omitempty"`
+	PartitionFields               []apiReflectionPartitionField `json:"partitionFields,omitempty"`
+	SortFields                    []apiReflectionField          `json:"sortFields,omitempty"`
+	PartitionDistributionStrategy string                        `json:"partitionDistributionStrategy,omitempty"`
+	TargetDatasetId               string                        `json:"targetDatasetId,omitempty"`
+}
+
+type apiReflection struct {
+	apiReflectionSpec
+	Id             string              `json:"id"`
+	Tag            string              `json:"tag"`
+	Status         apiReflectionStatus `json:"status"`
+	TotalSizeBytes int64               `json:"totalSizeBytes"`
+}
+
+// The type is kept as raw JSON, as its members depend on the type name.
+type apiDatasetField struct {
+	Name     string          `json:"name"`
+	Nullable *bool           `json:"nullable"`
+	Type     json.RawMessage `json:"type"`
+}
+
+type apiDatasetFieldType struct {
+	Name      string            `json:"name"`
+	Precision int               `json:"precision"`
+	Scale     int               `json:"scale"`
+	SubSchema []apiDatasetField `json:"subSchema"`
+}
+
+type apiJob struct {
+	JobState     string `json:"jobState"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+func newApiConnection(baseUrl string, config dapi.Config) *apiConnection {
+	baseUrl = strings.TrimSuffix(strings.TrimRight(baseUrl, "/"), "/api/v3")
+	return &apiConnection{
+		baseUrl: baseUrl,
+		config:  config,
+		http:    &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Returned for responses outside the 2xx range, so callers can tell a missing
+// entity or a version conflict apart from other failures.
+type apiError struct {
+	method     string
+	url        string
+	statusCode int
+	status     string
+	body       string
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("%s %s returned %s: %s", e.method, e.url, e.status, e.body)
+}
+
+func isApiStatus(err error, statusCode int) bool {
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.statusCode == statusCode
+}
+
+func (a *apiConnection) getAuthorization(ctx context.Context) (string, error) {
+	if a.config.ApiKey != "" {
+		return "Bearer " + a.config.ApiKey, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.authorization != "" {
+		return a.authorization, nil
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"userName": a.config.Username,
+		"password": a.config.Password,
+	})
+	if err != nil {
+		return "", err
+	}
+	var login struct {
+		Token string `json:"token"`
+	}
+	if err := a.send(ctx, http.MethodPost, a.baseUrl+"/apiv2/login", "", body, &login); err != nil {
+		return "", fmt.Errorf("failed to log in: %s", err)
+	}
+	a.authorization = "_dremio" + login.Token
+	return a.authorization, nil
+}
+
+// Drops a session token the server rejected, unless another request has
+// already replaced it.
+func (a *apiConnection) resetAuthorization(rejected string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.authorization == rejected {
+		a.authorization = ""
+	}
+}
+
+func (a *apiConnection) send(ctx context.Context, method string, url string, authorization string, body []byte, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	resp, err := a.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &apiError{
+			method:     method,
+			url:        url,
+			statusCode: resp.StatusCode,
+			status:     resp.Status,
+			body:       strings.TrimSpace(string(respBody)),
+		}
+	}
+	if out == nil || len(respBody) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(respBody))
+	// Keeps large numbers exact when decoding into untyped values
+	decoder.UseNumber()
+	return decoder.Decode(out)
+}
+
+// Sends in as the JSON request body when it is not nil and decodes the
+// response into out.
+func (a *apiConnection) do(ctx context.Context, method string, path string, in interface{}, out interface{}) error {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return err
+		}
+	}
+	for attempt := 0; ; attempt++ {
+		authorization, err := a.getAuthorization(ctx)
+		if err != nil {
+			return err
+		}
+		err = a.send(ctx, method, a.baseUrl+"/api/v3"+path, authorization, body, out)
+		// Session tokens expire, so log in again once when the token is rejected
+		if attempt == 0 && a.config.ApiKey == "" && isApiStatus(err, http.StatusUnauthorized) {
+			a.resetAuthorization(authorization)
+			continue
+		}
+		return err
+	}
+}
+
+func (a *apiConnection) getCatalogEntityByPath(ctx context.Context, path []string) (*apiCatalogEntity, error) {
+	parts := make([]string, len(path))
+	for i, part := range path {
+		parts[i] = url.PathEscape(part)
+	}
+	var entity apiCatalogEntity
+	if err := a.do(ctx, http.MethodGet, "/catalog/by-path/"+strings.Join(parts, "/"), nil, &entity); err != nil {
+		return nil, err
+	}
+	return &entity, nil
+}
+
+// Refreshes every reflection that depends on the dataset.
+func (a *apiConnection) refreshDatasetReflections(ctx context.Context, datasetId string) error {
+	return a.do(ctx, http.MethodPost, "/catalog/"+url.PathEscape(datasetId)+"/refresh", nil, nil)
+}
+
+func (a *apiConnection) getDatasetReflections(ctx context.Context, datasetId string) ([]apiReflection, error) {
+	var list struct {
+		Data []apiReflection `json:"data"`
+	}
+	if err := a.do(ctx, http.MethodGet, "/dataset/"+url.PathEscape(datasetId)+"/reflection", nil, &list); err != nil {
+		return nil, err
+	}
+	return list.Data, nil
+}
+
+// Submits the statement as a job and returns the job id.
+func (a *apiConnection) submitSql(ctx context.Context, sql string, sqlContext []string) (string, error) {
+	var job struct {
+		Id string `json:"id"`
+	}
+	in := map[string]interface{}{
+		"sql":     sql,
+		"context": sqlContext,
+	}
+	if err := a.do(ctx, http.MethodPost, "/sql", in, &job); err != nil {
+		return "", err
+	}
+	return job.Id, nil
+}
+
+// Polls the job until it has completed, failed or been canceled.
+func (a *apiConnection) waitForJob(ctx context.Context, jobId string, pollInterval time.Duration) (*apiJob, error) {
+	for {
+		var job apiJob
+		if err := a.do(ctx, http.MethodGet, "/job/"+url.PathEscape(jobId), nil, &job); err != nil {
+			return nil, err
+		}
+		switch job.JobState {
+		case "COMPLETED", "FAILED", "CANCELED":
+			return &job, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// Returns the paths of the datasets the dataset reads, from its lineage graph.
+func (a *apiConnection) getDatasetParents(ctx context.Context, datasetId string) ([][]string, error) {
+	var graph struct {
+		Parents []struct {
+			Path []string `json:"path"`
+		} `json:"parents"`
+	}
+	if err := a.do(ctx, http.MethodGet, "/catalog/"+url.PathEscape(datasetId)+"/graph", nil, &graph); err != nil {
+		return nil, err
+	}
+	paths := make([][]string, len(graph.Parents))
+	for i, parent := range graph.Parents {
+		paths[i] = parent.Path
+	}
+	return paths, nil
+}
+
+func (a *apiConnection) getDatasetFields(ctx context.Context, datasetId string) ([]apiDatasetField, error) {
+	var dataset struct {
+		Fields []apiDatasetField `json:"fields"`
+	}
+	if err := a.do(ctx, http.MethodGet, "/catalog/"+url.PathEscape(datasetId), nil, &dataset); err != nil {
+		return nil, err
+	}
+	return dataset.Fields, nil
+}
+
+// Returns nil when the dataset reports no policy.
+func (a *apiConnection) getDatasetAccelerationPolicy(ctx context.Context, datasetId string) (*apiAccelerationRefreshPolicy, error) {
+	var dataset struct {
+		AccelerationRefreshPolicy *apiAccelerationRefreshPolicy `json:"accelerationRefreshPolicy"`
+	}
+	if err := a.do(ctx, http.MethodGet, "/catalog/"+url.PathEscape(datasetId), nil, &dataset); err != nil {
+		return nil, err
+	}
+	return dataset.AccelerationRefreshPolicy, nil
+}
+
+func (a *apiConnection) getSourceAccelerationPolicy(ctx context.Context, sourceId string) (*apiAccelerationRefreshPolicy, error) {
+	var source struct {
+		RefreshPeriodMs  int    `json:"accelerationRefreshPeriodMs"`
+		GracePeriodMs    int    `json:"accelerationGracePeriodMs"`
+		NeverExpire      bool   `json:"accelerationNeverExpire"`
+		NeverRefresh     bool   `json:"accelerationNeverRefresh"`
+		ActivePolicyType string `json:"accelerationActivePolicyType"`
+		RefreshSchedule  string `json:"accelerationRefreshSchedule"`
+	}
+	if err := a.do(ctx, http.MethodGet, "/catalog/"+url.PathEscape(sourceId), nil, &source); err != nil {
+		return nil, err
+	}
+	return &apiAccelerationRefreshPolicy{
+		RefreshPeriodMs:  source.RefreshPeriodMs,
+		GracePeriodMs:    source.GracePeriodMs,
+		Method:           "FULL",
+		NeverExpire:      source.NeverExpire,
+		NeverRefresh:     source.NeverRefresh,
+		ActivePolicyType: source.ActivePolicyType,
+		RefreshSchedule:  source.RefreshSchedule,
+	}, nil
+}
+
+func (a *apiConnection) getEntityWikiText(ctx context.Context, entityId string) (string, error) {
+	var wiki struct {
+		Text string `json:"text"`
+	}
+	if err := a.do(ctx, http.MethodGet, "/catalog/"+url.PathEscape(entityId)+"/collaboration/wiki", nil, &wiki); err != nil {
+		return "", err
+	}
+	return wiki.Text, nil
+}
+
+func (a *apiConnection) createReflection(ctx context.Context, spec *apiReflectionSpec) (*apiReflection, error) {
+	var created apiReflection
+	if err := a.do(ctx, http.MethodPost, "/reflection", spec, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (a *apiConnection) getReflection(ctx context.Context, reflectionId string) (*apiReflection, error) {
+	var reflection apiReflection
+	if err := a.do(ctx, http.MethodGet, "/reflection/"+url.PathEscape(reflectionId), nil, &reflection); err != nil {
+		return nil, err
+	}
+	return &reflection, nil
+}
+
+// Replaces the reflection's settings. The current tag is sent with them, as
+// the API requires one.
+func (a *apiConnection) updateReflection(ctx context.Context, reflectionId string, spec *apiReflectionSpec) error {
+	current, err := a.getReflection(ctx, reflectionId)
+	if err != nil {
+		return err
+	}
+	update := struct {
+		*apiReflectionSpec
+		Id  string `json:"id"`
+		Tag string `json:"tag"`
+	}{spec, reflectionId, current.Tag}
+	return a.do(ctx, http.MethodPut, "/reflection/"+url.PathEscape(reflectionId), update, nil)
+}
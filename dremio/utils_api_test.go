@@ -0,0 +1,146 @@
+package dremio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+
+	dapi "github.com/saltxwater/go-dremio-api-client"
+)
+
+// Serves a login endpoint issuing numbered tokens, and accepts only the most
+// recent one on /api/v3/ping.
+func newTestLoginServer(t *testing.T) (*httptest.Server, *int) {
+	logins := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/apiv2/login", func(w http.ResponseWriter, r *http.Request) {
+		logins++
+		json.NewEncoder(w).Encode(map[string]string{"token": fmt.Sprintf("token%d", logins)})
+	})
+	mux.HandleFunc("/api/v3/ping", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != fmt.Sprintf("_dremiotoken%d", logins) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &logins
+}
+
+func TestApiConnectionLogsInAgainWhenTokenExpires(t *testing.T) {
+	server, logins := newTestLoginServer(t)
+	api := newApiConnection(server.URL+"/api/v3/", dapi.Config{Username: "user", Password: "secret"})
+	ctx := context.Background()
+
+	if err := api.do(ctx, http.MethodGet, "/ping", nil, nil); err != nil {
+		t.Fatalf("first request failed: %s", err)
+	}
+	// Expire the cached session token
+	*logins++
+	if err := api.do(ctx, http.MethodGet, "/ping", nil, nil); err != nil {
+		t.Fatalf("request after the token expired failed: %s", err)
+	}
+	if *logins != 3 {
+		t.Errorf("logged in %d times, want 3", *logins)
+	}
+}
+
+func TestApiConnectionStatusErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/api/v3/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+	api := newApiConnection(server.URL, dapi.Config{ApiKey: "key"})
+	ctx := context.Background()
+
+	if err := api.do(ctx, http.MethodGet, "/missing", nil, nil); !isApiStatus(err, http.StatusNotFound) {
+		t.Errorf("missing entity error = %v, want status 404", err)
+	}
+	if err := api.do(ctx, http.MethodGet, "/forbidden", nil, nil); isApiStatus(err, http.StatusNotFound) || err == nil {
+		t.Errorf("forbidden error = %v, want status 403", err)
+	}
+	// An api key cannot be renewed, so it is not retried
+	if err := api.do(ctx, http.MethodGet, "/other", nil, nil); !isApiStatus(err, http.StatusUnauthorized) {
+		t.Errorf("unauthorized error = %v, want status 401", err)
+	}
+}
+
+func TestApiConnectionCatalogEntityByPath(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v3/catalog/by-path/space/a%2Fb/view" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"id":"1","entityType":"dataset","type":"PHYSICAL_DATASET","path":["space","a/b","view"]}`))
+	}))
+	defer server.Close()
+	api := newApiConnection(server.URL, dapi.Config{ApiKey: "key"})
+	ctx := context.Background()
+
+	entity, err := api.getCatalogEntityByPath(ctx, []string{"space", "a/b", "view"})
+	if err != nil {
+		t.Fatalf("lookup failed: %s", err)
+	}
+	if entity.kind() != "PHYSICAL_DATASET" {
+		t.Errorf("kind = %q, want PHYSICAL_DATASET", entity.kind())
+	}
+	if _, err := api.getCatalogEntityByPath(ctx, []string{"space", "other"}); !isApiStatus(err, http.StatusNotFound) {
+		t.Errorf("missing entity error = %v, want status 404", err)
+	}
+}
+
+func TestUpdateCatalogEntity(t *testing.T) {
+	var written map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			w.Write([]byte(`{"id":"1","tag":"2","children":[],"metadataPolicy":{"authTTLMs":1,"datasetUpdateMode":"PREFETCH"}}`))
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&written)
+		if written["tag"] != "2" {
+			w.WriteHeader(http.StatusConflict)
+		}
+	}))
+	defer server.Close()
+	api := newApiConnection(server.URL, dapi.Config{ApiKey: "key"})
+	ctx := context.Background()
+	d := schema.TestResourceDataRaw(t, map[string]*schema.Schema{
+		"tag": {Type: schema.TypeString, Optional: true},
+	}, map[string]interface{}{})
+	d.SetId("1")
+
+	update := func(entity map[string]interface{}) {
+		mergeApiObject(entity, map[string]interface{}{
+			"metadataPolicy": map[string]interface{}{"authTTLMs": 5},
+		})
+	}
+	if err := updateCatalogEntity(ctx, api, d, update); err != nil {
+		t.Fatalf("update without a tag in state failed: %s", err)
+	}
+	policy := written["metadataPolicy"].(map[string]interface{})
+	if policy["authTTLMs"] != float64(5) || policy["datasetUpdateMode"] != "PREFETCH" {
+		t.Errorf("metadata policy = %v, want the managed key set and the other kept", policy)
+	}
+	if _, ok := written["children"]; ok {
+		t.Errorf("children were written back")
+	}
+
+	d.Set("tag", "1")
+	if err := updateCatalogEntity(ctx, api, d, update); err == nil || isApiStatus(err, http.StatusConflict) {
+		t.Errorf("stale tag error = %v, want the modified outside of Terraform error", err)
+	}
+}
@@ -0,0 +1,152 @@
+package dremio
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	dapi "github.com/saltxwater/go-dremio-api-client"
+)
+
+func makeCatalogSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
+	s["deletion_protection"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+		Default:  false,
+	}
+	return s
+}
+
+func makeContainerSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
+	s["force_destroy"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+		Default:  false,
+	}
+	return makeCatalogSchema(s)
+}
+
+func checkDeletionProtection(d *schema.ResourceData) error {
+	if d.Get("deletion_protection").(bool) {
+		return fmt.Errorf("cannot destroy catalog entity '%s' while deletion_protection is enabled", d.Id())
+	}
+	return nil
+}
+
+// Anything still inside the container at this point is not managed by this
+// configuration, as managed children are destroyed before their parent.
+func checkContainerEmpty(c *dapi.Client, d *schema.ResourceData) error {
+	if d.Get("force_destroy").(bool) {
+		return nil
+	}
+	entity, err := c.GetCatalogEntityById(d.Id())
+	if err != nil {
+		return err
+	}
+	if len(entity.Children) == 0 {
+		return nil
+	}
+	children := make([]string, len(entity.Children))
+	for i, child := range entity.Children {
+		children[i] = getQueryPath(child.Path)
+	}
+	return fmt.Errorf("cannot destroy non-empty container %s without force_destroy, it still contains: %s",
+		getQueryPath(entity.Path), strings.Join(children, ", "))
+}
+
+func makeAdoptableSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
+	s["adopt_existing"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+		Default:  false,
+	}
+	return s
+}
+
+// Catalog entity types, as reported by the catalog API. Datasets are told
+// apart by their dataset type.
+const (
+	catalogTypeSpace          = "space"
+	catalogTypeSource         = "source"
+	catalogTypeFolder         = "folder"
+	catalogTypeDataset        = "dataset"
+	catalogTypeVirtualDataset = "VIRTUAL_DATASET"
+)
+
+// Returns the id of the entity at the given path when adopt_existing is set
+// and the entity exists, so create can take ownership instead of failing.
+// An entity of a different type at the path is reported rather than adopted.
+func findAdoptableEntity(ctx context.Context, api *apiConnection, d *schema.ResourceData, path []string, entityType string) (string, bool, error) {
+	if !d.Get("adopt_existing").(bool) {
+		return "", false, nil
+	}
+	log.Printf("Looking for existing entity to adopt at path: %v", path)
+	entity, err := api.getCatalogEntityByPath(ctx, path)
+	if isApiStatus(err, http.StatusNotFound) {
+		log.Printf("No existing entity to adopt at path %v", path)
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if kind := entity.kind(); !strings.EqualFold(kind, entityType) {
+		return "", false, fmt.Errorf("cannot adopt %s, it is a %s rather than a %s", getQueryPath(entity.Path), kind, entityType)
+	}
+	log.Printf("Adopting existing entity with id '%s'", entity.Id)
+	return entity.Id, true, nil
+}
+
+func readCatalogTag(c *dapi.Client, d *schema.ResourceData) error {
+	entity, err := c.GetCatalogEntityById(d.Id())
+	if err != nil {
+		return err
+	}
+	return d.Set("tag", entity.Tag)
+}
+
+const catalogTagDescription = "Version tag of the entity when it was last read. Updates send it with the write, " +
+	"so they fail rather than overwrite a change made outside of Terraform since then."
+
+// Writes the entity back through the catalog API after update has applied the
+// managed settings to it. Settings the provider does not manage are kept as
+// read, and the version tag from state is sent with the write, so the server
+// rejects it if the entity was changed since it was last read.
+func updateCatalogEntity(ctx context.Context, api *apiConnection, d *schema.ResourceData, update func(entity map[string]interface{})) error {
+	path := "/catalog/" + url.PathEscape(d.Id())
+	var entity map[string]interface{}
+	if err := api.do(ctx, http.MethodGet, path, nil, &entity); err != nil {
+		return err
+	}
+	// Only reported on reads
+	delete(entity, "children")
+	delete(entity, "fields")
+
+	update(entity)
+	if tag := d.Get("tag").(string); tag != "" {
+		entity["tag"] = tag
+	}
+	err := api.do(ctx, http.MethodPut, path, entity, nil)
+	if isApiStatus(err, http.StatusConflict) {
+		return fmt.Errorf("catalog entity %s was modified outside of Terraform since it was last read (expected version tag '%s'), refresh and plan again",
+			d.Id(), d.Get("tag").(string))
+	}
+	return err
+}
+
+// Copies src into dst, merging nested objects key by key so that keys of dst
+// which src does not set are kept.
+func mergeApiObject(dst map[string]interface{}, src map[string]interface{}) {
+	for k, v := range src {
+		srcObj, srcIsObj := v.(map[string]interface{})
+		dstObj, dstIsObj := dst[k].(map[string]interface{})
+		if srcIsObj && dstIsObj {
+			mergeApiObject(dstObj, srcObj)
+			continue
+		}
+		dst[k] = v
+	}
+}
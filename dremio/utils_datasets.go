@@ -1,8 +1,15 @@
 package dremio
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
 
+	"github.com/hashicorp/terraform-plugin-sdk/v2/diag"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
 	dapi "github.com/saltxwater/go-dremio-api-client"
 )
@@ -56,27 +63,226 @@ func makePhysicalDatasetSchema(s map[string]*schema.Schema) map[string]*schema.S
 		Type:     schema.TypeBool,
 		Optional: true,
 	}
+	s = makeAccelerationScheduleSchema(s)
+	s["inherit_source_acceleration_policy"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+		Default:  false,
+		Description: "Send no acceleration policy for the dataset, so the source's policy applies. The effective_acc_ " +
+			"attributes show the policy in effect.",
+		ConflictsWith: []string{"acc_refresh_period_ms", "acc_grace_period_ms", "acc_method", "acc_refresh_field",
+			"acc_never_expire", "acc_never_refresh", "acc_policy_type", "acc_refresh_schedule"},
+	}
+	s["acc_policy_overridden"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Computed:    true,
+		Description: "Whether the dataset reports an acceleration policy different from its source's.",
+	}
+	s["effective_acc_refresh_period_ms"] = &schema.Schema{
+		Type:        schema.TypeInt,
+		Computed:    true,
+		Description: "Refresh period the dataset reports, or the source's when the dataset has no policy of its own.",
+	}
+	s["effective_acc_grace_period_ms"] = &schema.Schema{
+		Type:        schema.TypeInt,
+		Computed:    true,
+		Description: "Grace period in effect, worked out like `effective_acc_refresh_period_ms`.",
+	}
+	s["effective_acc_never_expire"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Computed:    true,
+		Description: "Never expire setting in effect, worked out like `effective_acc_refresh_period_ms`.",
+	}
+	s["effective_acc_never_refresh"] = &schema.Schema{
+		Type:        schema.TypeBool,
+		Computed:    true,
+		Description: "Never refresh setting in effect, worked out like `effective_acc_refresh_period_ms`.",
+	}
 	return makeDatasetSchema(s)
 }
 
+// Refresh schedules run at a time of day, every day or on the listed days of
+// the week, where 1 is Sunday. The server accepts no other cron expressions.
+var accelerationScheduleRegexp = regexp.MustCompile(`^0 ([0-9]|[1-5][0-9]) ([0-9]|1[0-9]|2[0-3]) \* \* (\*|[1-7](,[1-7])*)$`)
+
+// Adds the policy type and schedule arguments shared by sources and physical
+// datasets. Both are read from the server when not configured, as servers
+// before Dremio 24 do not have them.
+func makeAccelerationScheduleSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
+	s["acc_policy_type"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Optional:    true,
+		Computed:    true,
+		Description: "How reflections are refreshed: PERIOD every acc_refresh_period_ms, SCHEDULE on acc_refresh_schedule, or NEVER.",
+		ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
+			v := val.(string)
+			if v == "PERIOD" || v == "SCHEDULE" || v == "NEVER" {
+				return
+			}
+			errs = append(errs, fmt.Errorf("%s must be 'PERIOD', 'SCHEDULE' or 'NEVER', got: %s", key, v))
+			return
+		},
+	}
+	s["acc_refresh_schedule"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Optional: true,
+		Computed: true,
+		Description: "Cron expression of the refresh schedule, `0 <minute> <hour> * * <days>` where days is `*` or a " +
+			"comma separated list of weekdays from 1 (Sunday) to 7. Used when acc_policy_type is SCHEDULE.",
+		ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
+			v := val.(string)
+			if accelerationScheduleRegexp.MatchString(v) {
+				return
+			}
+			errs = append(errs, fmt.Errorf("%s must be a daily or weekly schedule like '0 30 2 * * *' or '0 0 8 * * 2,4', got: %s", key, v))
+			return
+		},
+	}
+	return s
+}
+
+// Lists the configured policy type and schedule under the given API keys.
+// Unset values are left out, so servers without schedules are not sent them.
+func accelerationScheduleToApi(d *schema.ResourceData, typeKey string, scheduleKey string) map[string]interface{} {
+	apiSchedule := make(map[string]interface{})
+	if v, ok := d.GetOk("acc_policy_type"); ok {
+		apiSchedule[typeKey] = v.(string)
+	}
+	if v, ok := d.GetOk("acc_refresh_schedule"); ok {
+		apiSchedule[scheduleKey] = v.(string)
+	}
+	return apiSchedule
+}
+
+func readAccelerationSchedule(d *schema.ResourceData, policyType string, schedule string) error {
+	if err := d.Set("acc_policy_type", policyType); err != nil {
+		return err
+	}
+	return d.Set("acc_refresh_schedule", schedule)
+}
+
+// Nested STRUCT and LIST fields are exposed as sub_fields down to this depth,
+// the complete type of deeper fields is still available from type_json.
+const datasetFieldsMaxDepth = 3
+
+func makeDatasetFieldsSchema(depth int) *schema.Schema {
+	s := map[string]*schema.Schema{
+		"name": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"type": {
+			Type:     schema.TypeString,
+			Computed: true,
+		},
+		"type_json": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "The field's type as the catalog API reports it, including any parameters and sub schema.",
+		},
+		"precision": {
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"scale": {
+			Type:     schema.TypeInt,
+			Computed: true,
+		},
+		"nullable": {
+			Type:        schema.TypeString,
+			Computed:    true,
+			Description: "`true` or `false`, or empty when Dremio does not report it.",
+		},
+	}
+	if depth > 1 {
+		s["sub_fields"] = makeDatasetFieldsSchema(depth - 1)
+	}
+	return &schema.Schema{
+		Type:     schema.TypeList,
+		Computed: true,
+		Elem: &schema.Resource{
+			Schema: s,
+		},
+	}
+}
+
+func flattenDatasetFields(fields []apiDatasetField, depth int) ([]interface{}, error) {
+	items := make([]interface{}, len(fields))
+	for i, field := range fields {
+		var fieldType apiDatasetFieldType
+		if err := json.Unmarshal(field.Type, &fieldType); err != nil {
+			return nil, err
+		}
+		nullable := ""
+		if field.Nullable != nil {
+			nullable = strconv.FormatBool(*field.Nullable)
+		}
+		item := map[string]interface{}{
+			"name":      field.Name,
+			"type":      fieldType.Name,
+			"type_json": string(field.Type),
+			"precision": fieldType.Precision,
+			"scale":     fieldType.Scale,
+			"nullable":  nullable,
+		}
+		if depth > 1 {
+			subFields, err := flattenDatasetFields(fieldType.SubSchema, depth-1)
+			if err != nil {
+				return nil, err
+			}
+			item["sub_fields"] = subFields
+		}
+		items[i] = item
+	}
+	return items, nil
+}
+
 func makeDatasetSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
-	s["fields"] = &schema.Schema{
-		Type:      schema.TypeList,
-		Computed:  true,
-		Sensitive: true,
+	s["fields"] = makeDatasetFieldsSchema(datasetFieldsMaxDepth)
+	s["expected_fields"] = &schema.Schema{
+		Type:     schema.TypeList,
+		Optional: true,
 		Elem: &schema.Resource{
 			Schema: map[string]*schema.Schema{
 				"name": {
 					Type:     schema.TypeString,
-					Computed: true,
+					Required: true,
 				},
 				"type": {
 					Type:     schema.TypeString,
-					Computed: true,
+					Optional: true,
+				},
+				"nullable": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Description: "`true` or `false`. Only checked when Dremio reports the field's nullability.",
+					ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
+						v := val.(string)
+						if v == "" || v == "true" || v == "false" {
+							return
+						}
+						errs = append(errs, fmt.Errorf("%s must be 'true' or 'false', got: %s", key, v))
+						return
+					},
 				},
 			},
 		},
 	}
+	s["expected_fields_mode"] = &schema.Schema{
+		Type:     schema.TypeString,
+		Optional: true,
+		Default:  "error",
+		Description: "How a dataset that does not match expected_fields is reported. `error` fails the plan, or the apply " +
+			"when the write itself changes the schema, and `warn` only reports a warning. Refresh only ever warns, so it never blocks a plan or destroy.",
+		ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
+			v := val.(string)
+			if v == "error" || v == "warn" {
+				return
+			}
+			errs = append(errs, fmt.Errorf("%s must be 'error' or 'warn', got: %s", key, v))
+			return
+		},
+	}
 	s["path"] = &schema.Schema{
 		Type:     schema.TypeList,
 		Computed: true,
@@ -88,80 +294,239 @@ func makeDatasetSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
 		Type:     schema.TypeString,
 		Computed: true,
 	}
-	return s
+	s["tag"] = &schema.Schema{
+		Type:        schema.TypeString,
+		Computed:    true,
+		Description: catalogTagDescription,
+	}
+	return makeCatalogSchema(s)
 }
 
-func readPhysicalDatasetRefreshPolicy(d *schema.ResourceData, pds *dapi.PhysicalDataset) error {
-	acc := pds.AccelerationRefreshPolicy
+func readPhysicalDatasetRefreshPolicy(d *schema.ResourceData, acc *apiAccelerationRefreshPolicy) error {
 	if acc == nil {
-		if err := d.Set("acc_refresh_period_ms", 0); err != nil {
-			return err
-		}
-		if err := d.Set("acc_grace_period_ms", 0); err != nil {
-			return err
-		}
-		if err := d.Set("acc_method", ""); err != nil {
-			return err
-		}
-		if err := d.Set("acc_refresh_field", ""); err != nil {
-			return err
-		}
-		if err := d.Set("acc_never_expire", false); err != nil {
-			return err
-		}
-		if err := d.Set("acc_never_refresh", false); err != nil {
-			return err
-		}
-	} else {
-		if err := d.Set("acc_refresh_period_ms", acc.RefreshPeriodMs); err != nil {
-			return err
-		}
-		if err := d.Set("acc_grace_period_ms", acc.GracePeriodMs); err != nil {
-			return err
-		}
-		if err := d.Set("acc_method", acc.Method); err != nil {
-			return err
+		acc = &apiAccelerationRefreshPolicy{}
+	}
+	if err := d.Set("acc_refresh_period_ms", acc.RefreshPeriodMs); err != nil {
+		return err
+	}
+	if err := d.Set("acc_grace_period_ms", acc.GracePeriodMs); err != nil {
+		return err
+	}
+	if err := d.Set("acc_method", acc.Method); err != nil {
+		return err
+	}
+	if err := d.Set("acc_refresh_field", acc.RefreshField); err != nil {
+		return err
+	}
+	if err := d.Set("acc_never_expire", acc.NeverExpire); err != nil {
+		return err
+	}
+	if err := d.Set("acc_never_refresh", acc.NeverRefresh); err != nil {
+		return err
+	}
+	return readAccelerationSchedule(d, acc.ActivePolicyType, acc.RefreshSchedule)
+}
+
+// Fetches any dataset by id, whether it is virtual or physical. The view is
+// also returned when the dataset is virtual.
+func getDatasetById(c *dapi.Client, datasetId string) (*dapi.Dataset, *dapi.VirtualDataset, error) {
+	vds, err := c.GetVirtualDataset(datasetId)
+	if err == nil {
+		return &vds.Dataset, vds, nil
+	}
+	pds, err := c.GetPhysicalDataset(datasetId)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &pds.Dataset, nil, nil
+}
+
+// The client only decodes the name of each field's type, so the fields are
+// read from the catalog API to get the full type.
+func readDatasetCommon(ctx context.Context, api *apiConnection, d *schema.ResourceData, ds *dapi.Dataset) error {
+	datasetFields, err := api.getDatasetFields(ctx, ds.Id)
+	if err != nil {
+		return err
+	}
+	fields, err := flattenDatasetFields(datasetFields, datasetFieldsMaxDepth)
+	if err != nil {
+		return err
+	}
+	if err := d.Set("fields", fields); err != nil {
+		return err
+	}
+
+	if err := d.Set("path", ds.Path); err != nil {
+		return err
+	}
+
+	if err := d.Set("query_path", getQueryPath(ds.Path)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Compares the fields read from Dremio against expected_fields, returning
+// missing columns and type or nullability changes.
+func compareExpectedFields(expectedFields []interface{}, fields []interface{}) []string {
+	live := make(map[string]map[string]interface{})
+	for _, raw := range fields {
+		field := raw.(map[string]interface{})
+		live[strings.ToLower(field["name"].(string))] = field
+	}
+
+	problems := make([]string, 0)
+	for _, raw := range expectedFields {
+		expected := raw.(map[string]interface{})
+		name := expected["name"].(string)
+		field, ok := live[strings.ToLower(name)]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("field '%s' is missing", name))
+			continue
 		}
-		if err := d.Set("acc_refresh_field", acc.RefreshField); err != nil {
-			return err
+		liveType := field["type"].(string)
+		if expectedType := expected["type"].(string); expectedType != "" && !strings.EqualFold(expectedType, liveType) {
+			problems = append(problems, fmt.Sprintf("field '%s' has type %s, expected %s", name, liveType, expectedType))
 		}
-		if err := d.Set("acc_never_expire", acc.NeverExpire); err != nil {
-			return err
+		liveNullable, _ := field["nullable"].(string)
+		if expectedNullable, _ := expected["nullable"].(string); expectedNullable != "" && liveNullable != "" && expectedNullable != liveNullable {
+			problems = append(problems, fmt.Sprintf("field '%s' has nullable %s, expected %s", name, liveNullable, expectedNullable))
 		}
-		if err := d.Set("acc_never_refresh", acc.NeverRefresh); err != nil {
-			return err
+	}
+	return problems
+}
+
+func expectedFieldsSummary(queryPath string) string {
+	return fmt.Sprintf("Schema of dataset %s does not match expected_fields", queryPath)
+}
+
+// Checks the fields read from Dremio. Read passes diag.Warning, so a broken
+// contract never fails a refresh, while create and update pass the severity
+// configured by expected_fields_mode.
+func checkExpectedFields(d *schema.ResourceData, severity diag.Severity) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	problems := compareExpectedFields(d.Get("expected_fields").([]interface{}), d.Get("fields").([]interface{}))
+	if len(problems) == 0 {
+		return diags
+	}
+	return append(diags, diag.Diagnostic{
+		Severity: severity,
+		Summary:  expectedFieldsSummary(d.Get("query_path").(string)),
+		Detail:   strings.Join(problems, "\n"),
+	})
+}
+
+func expectedFieldsSeverity(d *schema.ResourceData) diag.Severity {
+	if d.Get("expected_fields_mode").(string) == "warn" {
+		return diag.Warning
+	}
+	return diag.Error
+}
+
+// Used by create and update in place of the warning from read.
+func enforceExpectedFields(d *schema.ResourceData, diags diag.Diagnostics) diag.Diagnostics {
+	if diags.HasError() {
+		return diags
+	}
+	summary := expectedFieldsSummary(d.Get("query_path").(string))
+	checked := make(diag.Diagnostics, 0, len(diags))
+	for _, diagnostic := range diags {
+		if diagnostic.Summary != summary {
+			checked = append(checked, diagnostic)
 		}
 	}
-	return nil
+	return append(checked, checkExpectedFields(d, expectedFieldsSeverity(d))...)
 }
 
-func readDatasetCommon(d *schema.ResourceData, ds *dapi.Dataset) error {
-	fields := make([]map[string]string, len(ds.Fields))
-	for i, field := range ds.Fields {
-		fields[i] = map[string]string{
-			"name": field.Name,
-			"type": field.Type.Name,
+// Checks the configured expected_fields against the fields last read, so a
+// broken contract is reported by the plan. New datasets, and changes to the
+// arguments in schemaKeys, leave the fields unknown until apply, where
+// create and update check them instead.
+func checkExpectedFieldsOnPlan(d *schema.ResourceDiff, schemaKeys ...string) error {
+	if d.Id() == "" {
+		return nil
+	}
+	for _, key := range schemaKeys {
+		if d.HasChange(key) {
+			return d.SetNewComputed("fields")
 		}
 	}
-	if err := d.Set("fields", fields); err != nil {
-		return err
+	problems := compareExpectedFields(d.Get("expected_fields").([]interface{}), d.Get("fields").([]interface{}))
+	if len(problems) == 0 {
+		return nil
+	}
+	summary := expectedFieldsSummary(d.Get("query_path").(string))
+	if d.Get("expected_fields_mode").(string) == "warn" {
+		log.Printf("[WARN] %s: %s", summary, strings.Join(problems, ", "))
+		return nil
 	}
+	return fmt.Errorf("%s: %s", summary, strings.Join(problems, ", "))
+}
 
-	if err := d.Set("path", ds.Path); err != nil {
+// The policy in effect is the one the dataset reports, or the source's when
+// the dataset has none of its own. A reported policy that differs from the
+// source's overrides it.
+func readEffectiveAccelerationPolicy(ctx context.Context, api *apiConnection, d *schema.ResourceData, acc *apiAccelerationRefreshPolicy) error {
+	source, err := api.getSourceAccelerationPolicy(ctx, d.Get("source_id").(string))
+	if err != nil {
+		return err
+	}
+	if err := d.Set("acc_policy_overridden", acc != nil && *acc != *source); err != nil {
+		return err
+	}
+	if acc == nil {
+		acc = source
+	}
+	if err := d.Set("effective_acc_refresh_period_ms", acc.RefreshPeriodMs); err != nil {
 		return err
 	}
+	if err := d.Set("effective_acc_grace_period_ms", acc.GracePeriodMs); err != nil {
+		return err
+	}
+	if err := d.Set("effective_acc_never_expire", acc.NeverExpire); err != nil {
+		return err
+	}
+	return d.Set("effective_acc_never_refresh", acc.NeverRefresh)
+}
 
-	if err := d.Set("query_path", getQueryPath(ds.Path)); err != nil {
+// Inheriting removes the dataset's own policy on the next apply, after which
+// the source's policy is in effect.
+func planInheritedAccelerationPolicy(d *schema.ResourceDiff) error {
+	if !d.Get("inherit_source_acceleration_policy").(bool) || !d.Get("acc_policy_overridden").(bool) {
+		return nil
+	}
+	if err := d.SetNew("acc_policy_overridden", false); err != nil {
 		return err
 	}
+	for _, key := range []string{"effective_acc_refresh_period_ms", "effective_acc_grace_period_ms", "effective_acc_never_expire", "effective_acc_never_refresh"} {
+		if err := d.SetNewComputed(key); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func readPhysicalDatasetCommon(d *schema.ResourceData, pds *dapi.PhysicalDataset) error {
-	if err := readPhysicalDatasetRefreshPolicy(d, pds); err != nil {
+// The client does not decode the policy type and schedule, so the policy is
+// read from the catalog API.
+func readPhysicalDatasetCommon(ctx context.Context, m interface{}, d *schema.ResourceData, pds *dapi.PhysicalDataset) error {
+	api := m.(*providerMeta).api
+
+	acc, err := api.getDatasetAccelerationPolicy(ctx, pds.Id)
+	if err != nil {
 		return err
 	}
-	return readDatasetCommon(d, &pds.Dataset)
+	// An inherited policy is not owned by the dataset, so the acc_ arguments
+	// are left as configured rather than showing the source's values as drift
+	if !d.Get("inherit_source_acceleration_policy").(bool) {
+		if err := readPhysicalDatasetRefreshPolicy(d, acc); err != nil {
+			return err
+		}
+	}
+	if err := readEffectiveAccelerationPolicy(ctx, api, d, acc); err != nil {
+		return err
+	}
+	return readDatasetCommon(ctx, api, d, &pds.Dataset)
 }
 
 func getDatasetAccelerationRefreshPolicy(d *schema.ResourceData) *dapi.DatasetAccelerationRefreshPolicy {
@@ -175,6 +540,31 @@ func getDatasetAccelerationRefreshPolicy(d *schema.ResourceData) *dapi.DatasetAc
 	}
 }
 
+func accelerationRefreshPolicyToApi(acc *dapi.DatasetAccelerationRefreshPolicy) map[string]interface{} {
+	return map[string]interface{}{
+		"refreshPeriodMs": acc.RefreshPeriodMs,
+		"gracePeriodMs":   acc.GracePeriodMs,
+		"method":          acc.Method,
+		"refreshField":    acc.RefreshField,
+		"neverExpire":     acc.NeverExpire,
+		"neverRefresh":    acc.NeverRefresh,
+	}
+}
+
+// Applies the configured policy to the catalog entity. An inheriting dataset
+// is sent no policy of its own, so the source's applies.
+func applyDatasetAccelerationPolicy(d *schema.ResourceData, entity map[string]interface{}) {
+	if d.Get("inherit_source_acceleration_policy").(bool) {
+		delete(entity, "accelerationRefreshPolicy")
+		return
+	}
+	apiPolicy := accelerationRefreshPolicyToApi(getDatasetAccelerationRefreshPolicy(d))
+	mergeApiObject(apiPolicy, accelerationScheduleToApi(d, "activePolicyType", "refreshSchedule"))
+	mergeApiObject(entity, map[string]interface{}{
+		"accelerationRefreshPolicy": apiPolicy,
+	})
+}
+
 func getPhysicalDatasetAbsolutePath(c *dapi.Client, d *schema.ResourceData) ([]string, error) {
 	return getAbsolutePath(c, d.Get("source_id").(string), d.Get("relative_path").([]interface{}))
 }
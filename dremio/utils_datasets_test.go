@@ -0,0 +1,118 @@
+package dremio
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func TestFlattenDatasetFields(t *testing.T) {
+	var fields []apiDatasetField
+	raw := `[
+		{"name":"amount","type":{"name":"DECIMAL","precision":10,"scale":2},"nullable":false},
+		{"name":"address","type":{"name":"STRUCT","subSchema":[
+			{"name":"lines","type":{"name":"LIST","subSchema":[
+				{"name":"$data$","type":{"name":"VARCHAR"}}
+			]}}
+		]}}
+	]`
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		t.Fatal(err)
+	}
+	items, err := flattenDatasetFields(fields, datasetFieldsMaxDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	amount := items[0].(map[string]interface{})
+	if amount["type"] != "DECIMAL" || amount["precision"] != 10 || amount["scale"] != 2 || amount["nullable"] != "false" {
+		t.Errorf("amount = %v, want DECIMAL(10, 2) not nullable", amount)
+	}
+	address := items[1].(map[string]interface{})
+	if address["nullable"] != "" {
+		t.Errorf("nullable = %q, want empty when not reported", address["nullable"])
+	}
+	lines := address["sub_fields"].([]interface{})[0].(map[string]interface{})
+	if lines["type"] != "LIST" || lines["type_json"] == "" {
+		t.Errorf("lines = %v, want a LIST with its type json", lines)
+	}
+	// The deepest level has no sub_fields, only type_json
+	data := lines["sub_fields"].([]interface{})[0].(map[string]interface{})
+	if _, ok := data["sub_fields"]; ok || data["type"] != "VARCHAR" {
+		t.Errorf("data = %v, want a VARCHAR without sub_fields", data)
+	}
+}
+
+func TestCompareExpectedFields(t *testing.T) {
+	fields := []interface{}{
+		map[string]interface{}{"name": "id", "type": "BIGINT", "nullable": "false"},
+		map[string]interface{}{"name": "Name", "type": "VARCHAR", "nullable": ""},
+	}
+	expected := []interface{}{
+		map[string]interface{}{"name": "id", "type": "bigint", "nullable": "true"},
+		map[string]interface{}{"name": "name", "type": "", "nullable": "false"},
+		map[string]interface{}{"name": "amount", "type": "DECIMAL", "nullable": ""},
+	}
+	got := compareExpectedFields(expected, fields)
+	want := []string{
+		"field 'id' has nullable false, expected true",
+		"field 'amount' is missing",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("compareExpectedFields() = %q, want %q", got, want)
+	}
+}
+
+func TestAccelerationScheduleValidation(t *testing.T) {
+	validate := makeAccelerationScheduleSchema(map[string]*schema.Schema{})["acc_refresh_schedule"].ValidateFunc
+	for _, schedule := range []string{"0 0 8 * * *", "0 30 23 * * 1", "0 5 2 * * 2,4,6"} {
+		if _, errs := validate(schedule, "acc_refresh_schedule"); len(errs) > 0 {
+			t.Errorf("schedule %q rejected: %v", schedule, errs)
+		}
+	}
+	for _, schedule := range []string{"0 0 24 * * *", "0 60 8 * * *", "30 0 8 * * *", "0 0 8 1 * *", "0 0 8 * * 0", "0 0 8 * * 1-5", "0 0 8 * * 1,"} {
+		if _, errs := validate(schedule, "acc_refresh_schedule"); len(errs) == 0 {
+			t.Errorf("schedule %q accepted", schedule)
+		}
+	}
+}
+
+func TestApplyDatasetAccelerationPolicy(t *testing.T) {
+	s := resourcePhysicalDataset().Schema
+	entity := func() map[string]interface{} {
+		return map[string]interface{}{
+			"id":                        "1",
+			"accelerationRefreshPolicy": map[string]interface{}{"refreshPeriodMs": 1, "gracePeriodMs": 2},
+		}
+	}
+
+	inherit := schema.TestResourceDataRaw(t, s, map[string]interface{}{
+		"inherit_source_acceleration_policy": true,
+	})
+	inherited := entity()
+	applyDatasetAccelerationPolicy(inherit, inherited)
+	if _, ok := inherited["accelerationRefreshPolicy"]; ok {
+		t.Errorf("inheriting dataset sent a policy: %v", inherited["accelerationRefreshPolicy"])
+	}
+
+	own := schema.TestResourceDataRaw(t, s, map[string]interface{}{
+		"acc_refresh_period_ms": 3600000,
+		"acc_policy_type":       "SCHEDULE",
+		"acc_refresh_schedule":  "0 0 8 * * *",
+	})
+	owned := entity()
+	applyDatasetAccelerationPolicy(own, owned)
+	policy := owned["accelerationRefreshPolicy"].(map[string]interface{})
+	want := map[string]interface{}{
+		"refreshPeriodMs":  3600000,
+		"activePolicyType": "SCHEDULE",
+		"refreshSchedule":  "0 0 8 * * *",
+	}
+	for k, v := range want {
+		if policy[k] != v {
+			t.Errorf("policy %s = %v, want %v", k, policy[k], v)
+		}
+	}
+}
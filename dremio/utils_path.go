@@ -24,3 +24,8 @@ func getQueryPath(path []string) string {
 	}
 	return strings.Join(qp, ".")
 }
+
+// Reverses getQueryPath.
+func splitQueryPath(qp string) []string {
+	return strings.Split(strings.TrimSuffix(strings.TrimPrefix(qp, "\""), "\""), "\".\"")
+}
@@ -0,0 +1,517 @@
+package dremio
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+func makeReflectionStatusSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
+	s["wait_for_materialization"] = &schema.Schema{
+		Type:     schema.TypeBool,
+		Optional: true,
+		Default:  false,
+		Description: "Wait on create and update until the reflection can accelerate queries, failing if it fails to materialize " +
+			"or the timeout passes. Disabled reflections, and ones that are never refreshed, are not waited for.",
+	}
+	for _, key := range []string{"status_config", "status_refresh", "status_availability", "last_refresh_at", "expires_at"} {
+		s[key] = &schema.Schema{
+			Type:     schema.TypeString,
+			Computed: true,
+		}
+	}
+	s["total_size_bytes"] = &schema.Schema{
+		Type:     schema.TypeInt,
+		Computed: true,
+	}
+	return s
+}
+
+func readReflectionStatus(d *schema.ResourceData, reflection *apiReflection) error {
+	if err := d.Set("status_config", reflection.Status.Config); err != nil {
+		return err
+	}
+	if err := d.Set("status_refresh", reflection.Status.Refresh); err != nil {
+		return err
+	}
+	if err := d.Set("status_availability", reflection.Status.Availability); err != nil {
+		return err
+	}
+	if err := d.Set("last_refresh_at", reflection.Status.LastDataFetch); err != nil {
+		return err
+	}
+	if err := d.Set("expires_at", reflection.Status.ExpiresAt); err != nil {
+		return err
+	}
+	return d.Set("total_size_bytes", int(reflection.TotalSizeBytes))
+}
+
+// Polls the reflection until it can accelerate queries, failing if it fails
+// to materialize before the timeout.
+func waitForReflectionMaterialization(ctx context.Context, api *apiConnection, d *schema.ResourceData, timeout time.Duration) error {
+	if !d.Get("wait_for_materialization").(bool) {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	for {
+		reflection, err := api.getReflection(ctx, d.Id())
+		if err != nil {
+			return err
+		}
+		status := reflection.Status
+		log.Printf("Reflection '%s' status: %s, refresh: %s", d.Id(), status.CombinedStatus, status.Refresh)
+
+		// Neither is ever materialized, so waiting would only run into the timeout
+		if !reflection.Enabled || status.Refresh == "MANUAL" {
+			log.Printf("Not waiting for reflection '%s' to materialize, it is disabled or never refreshed", d.Id())
+			return nil
+		}
+		switch status.CombinedStatus {
+		case "CAN_ACCELERATE", "CAN_ACCELERATE_WITH_FAILURES":
+			return nil
+		case "FAILED", "INVALID":
+			return fmt.Errorf("reflection '%s' failed to materialize, status: %s, refresh: %s", d.Id(), status.CombinedStatus, status.Refresh)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for reflection '%s' to materialize, last status: %s", d.Id(), status.CombinedStatus)
+		case <-time.After(reflectionRefreshPollInterval):
+		}
+	}
+}
+
+// The fields are validated on the planned diff and again on the applied
+// configuration, which is read the same way from either.
+type reflectionConfig interface {
+	Get(key string) interface{}
+}
+
+// Values of the applied configuration are always known.
+func reflectionValueKnown(d reflectionConfig, key string) bool {
+	if diff, ok := d.(*schema.ResourceDiff); ok {
+		return diff.NewValueKnown(key)
+	}
+	return true
+}
+
+type reflectionFieldRef struct {
+	key       string
+	path      string
+	name      string
+	transform string
+}
+
+func collectReflectionFieldRefs(d reflectionConfig, keys ...string) []reflectionFieldRef {
+	refs := make([]reflectionFieldRef, 0)
+	for _, key := range keys {
+		if !reflectionValueKnown(d, key) {
+			continue
+		}
+		if set, ok := d.Get(key).(*schema.Set); ok {
+			// Set elements have no stable index to report
+			for _, raw := range set.List() {
+				refs = append(refs, reflectionFieldRef{key: key, path: key, name: raw.(string)})
+			}
+			continue
+		}
+		for i, raw := range d.Get(key).([]interface{}) {
+			refs = append(refs, reflectionFieldRef{
+				key:  key,
+				path: fmt.Sprintf("%s.%d", key, i),
+				name: raw.(string),
+			})
+		}
+	}
+	return refs
+}
+
+var reflectionPartitionTransforms = []string{"IDENTITY", "YEAR", "MONTH", "DAY", "HOUR", "BUCKET", "TRUNCATE"}
+
+// Transforms taking a number, the bucket count or truncate length.
+var reflectionPartitionTransformsWithArg = map[string]bool{
+	"BUCKET":   true,
+	"TRUNCATE": true,
+}
+
+var reflectionTimeTransforms = map[string]bool{
+	"YEAR":  true,
+	"MONTH": true,
+	"DAY":   true,
+	"HOUR":  true,
+}
+
+// Adds the partitioning arguments shared by raw and aggregation reflections.
+func makeReflectionPartitionSchema(s map[string]*schema.Schema) map[string]*schema.Schema {
+	s["partition_fields"] = &schema.Schema{
+		Type:          schema.TypeSet,
+		Optional:      true,
+		ConflictsWith: []string{"partition_field"},
+		Description:   "Names of the fields to partition by. Use partition_field blocks to apply partition transforms.",
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+	}
+	s["partition_field"] = &schema.Schema{
+		Type:          schema.TypeSet,
+		Optional:      true,
+		ConflictsWith: []string{"partition_fields"},
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": {
+					Type:     schema.TypeString,
+					Required: true,
+				},
+				"transform": {
+					Type:        schema.TypeString,
+					Optional:    true,
+					Default:     "IDENTITY",
+					Description: "One of " + strings.Join(reflectionPartitionTransforms, ", ") + ".",
+					ValidateFunc: func(val interface{}, key string) (warns []string, errs []error) {
+						v := val.(string)
+						for _, t := range reflectionPartitionTransforms {
+							if v == t {
+								return
+							}
+						}
+						errs = append(errs, fmt.Errorf("%s must be one of %s, got: %s", key, strings.Join(reflectionPartitionTransforms, ", "), v))
+						return
+					},
+				},
+				"transform_arg": {
+					Type:        schema.TypeInt,
+					Optional:    true,
+					Description: "The bucket count for BUCKET or the length for TRUNCATE. Not used by the other transforms.",
+				},
+			},
+		},
+	}
+	return s
+}
+
+func getPartitionFields(d *schema.ResourceData) []apiReflectionPartitionField {
+	items := make([]apiReflectionPartitionField, 0)
+	for _, raw := range d.Get("partition_fields").(*schema.Set).List() {
+		items = append(items, apiReflectionPartitionField{Name: raw.(string)})
+	}
+	for _, raw := range d.Get("partition_field").(*schema.Set).List() {
+		field := raw.(map[string]interface{})
+		item := apiReflectionPartitionField{Name: field["name"].(string)}
+		switch transform := field["transform"].(string); transform {
+		case "IDENTITY":
+			// Sent without a transform, as servers without transform support expect
+		case "BUCKET":
+			item.Transform = &apiPartitionTransform{Type: transform, BucketTransform: &apiBucketTransform{BucketCount: field["transform_arg"].(int)}}
+		case "TRUNCATE":
+			item.Transform = &apiPartitionTransform{Type: transform, TruncateTransform: &apiTruncateTransform{TruncateLength: field["transform_arg"].(int)}}
+		default:
+			item.Transform = &apiPartitionTransform{Type: transform}
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// Reads the partitioning back into partition_fields while it only uses plain
+// names and partition_field is not in use, so existing configurations keep
+// working, and into partition_field otherwise.
+func setPartitionFields(d *schema.ResourceData, fields []apiReflectionPartitionField) error {
+	names := make([]string, 0, len(fields))
+	blocks := make([]interface{}, 0, len(fields))
+	identityOnly := true
+	for _, field := range fields {
+		transform, arg := "IDENTITY", 0
+		if field.Transform != nil && field.Transform.Type != "" {
+			transform = field.Transform.Type
+		}
+		if field.Transform != nil && field.Transform.BucketTransform != nil {
+			arg = field.Transform.BucketTransform.BucketCount
+		}
+		if field.Transform != nil && field.Transform.TruncateTransform != nil {
+			arg = field.Transform.TruncateTransform.TruncateLength
+		}
+		identityOnly = identityOnly && transform == "IDENTITY"
+		names = append(names, field.Name)
+		blocks = append(blocks, map[string]interface{}{
+			"name":          field.Name,
+			"transform":     transform,
+			"transform_arg": arg,
+		})
+	}
+	if identityOnly && d.Get("partition_field").(*schema.Set).Len() == 0 {
+		if err := d.Set("partition_field", nil); err != nil {
+			return err
+		}
+		return d.Set("partition_fields", names)
+	}
+	if err := d.Set("partition_fields", nil); err != nil {
+		return err
+	}
+	return d.Set("partition_field", blocks)
+}
+
+// Collects the fields of partition_field blocks for validation, checking that
+// transform_arg is set exactly for the transforms that take it.
+func collectPartitionFieldRefs(d reflectionConfig) ([]reflectionFieldRef, error) {
+	refs := make([]reflectionFieldRef, 0)
+	if !reflectionValueKnown(d, "partition_field") {
+		return refs, nil
+	}
+	for _, raw := range d.Get("partition_field").(*schema.Set).List() {
+		field := raw.(map[string]interface{})
+		name, transform, arg := field["name"].(string), field["transform"].(string), field["transform_arg"].(int)
+		if reflectionPartitionTransformsWithArg[transform] && arg <= 0 {
+			return nil, fmt.Errorf("partition_field: field '%s' needs a positive transform_arg for the %s transform", name, transform)
+		}
+		if !reflectionPartitionTransformsWithArg[transform] && arg != 0 {
+			return nil, fmt.Errorf("partition_field: field '%s' sets transform_arg, which the %s transform does not take", name, transform)
+		}
+		refs = append(refs, reflectionFieldRef{key: "partition_fields", path: "partition_field", name: name, transform: transform})
+	}
+	return refs, nil
+}
+
+var nonPartitionableTypes = map[string]bool{
+	"STRUCT": true,
+	"LIST":   true,
+	"MAP":    true,
+	"UNION":  true,
+}
+
+// Checks the referenced fields against the dataset's schema, at plan time so
+// typos are reported before anything is applied, and again before writing.
+// While planning, fields missing from the dataset are only logged, as changes
+// in the same apply may add them.
+func validateReflectionFields(ctx context.Context, api *apiConnection, d reflectionConfig, refs []reflectionFieldRef) error {
+	_, planning := d.(*schema.ResourceDiff)
+	if !reflectionValueKnown(d, "dataset_id") {
+		return nil
+	}
+	datasetId := d.Get("dataset_id").(string)
+	types, err := getDatasetFieldTypes(ctx, api, datasetId)
+	if planning && isApiStatus(err, http.StatusNotFound) {
+		// The dataset may not exist until it is created in this apply
+		log.Printf("Skipping reflection field validation, dataset '%s' not found", datasetId)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	problems := make([]string, 0)
+	for _, ref := range refs {
+		fieldType, ok := types[strings.ToLower(ref.name)]
+		if !ok && planning {
+			log.Printf("%s: field '%s' is not in dataset '%s' yet, it is checked again on apply", ref.path, ref.name, datasetId)
+			continue
+		}
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: field '%s' does not exist in dataset '%s'", ref.path, ref.name, datasetId))
+			continue
+		}
+		if ref.key == "partition_fields" && nonPartitionableTypes[fieldType] {
+			problems = append(problems, fmt.Sprintf("%s: field '%s' of type %s cannot be used for partitioning", ref.path, ref.name, fieldType))
+		}
+		if reflectionTimeTransforms[ref.transform] && fieldType != "DATE" && fieldType != "TIMESTAMP" {
+			problems = append(problems, fmt.Sprintf("%s: field '%s' of type %s cannot use the %s transform, which needs a DATE or TIMESTAMP", ref.path, ref.name, fieldType, ref.transform))
+		}
+		if ref.key == "timestamp_date_dimension_fields" && fieldType != "DATE" && fieldType != "TIMESTAMP" {
+			problems = append(problems, fmt.Sprintf("%s: field '%s' of type %s must be a DATE or TIMESTAMP", ref.path, ref.name, fieldType))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid reflection fields:\n%s", strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// Maps the lower cased names of the dataset's top level fields to their type
+// names.
+func getDatasetFieldTypes(ctx context.Context, api *apiConnection, datasetId string) (map[string]string, error) {
+	fields, err := api.getDatasetFields(ctx, datasetId)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[string]string)
+	for _, field := range fields {
+		var fieldType apiDatasetFieldType
+		if err := json.Unmarshal(field.Type, &fieldType); err != nil {
+			return nil, err
+		}
+		types[strings.ToLower(field.Name)] = fieldType.Name
+	}
+	return types, nil
+}
+
+// An external reflection substitutes the target for the dataset, so every
+// field of the dataset must be in the target with the same type.
+func checkExternalReflectionFields(ctx context.Context, api *apiConnection, datasetId string, targetId string) error {
+	fields, err := api.getDatasetFields(ctx, datasetId)
+	if err != nil {
+		return err
+	}
+	targetTypes, err := getDatasetFieldTypes(ctx, api, targetId)
+	if err != nil {
+		return err
+	}
+
+	problems := make([]string, 0)
+	for _, field := range fields {
+		var fieldType apiDatasetFieldType
+		if err := json.Unmarshal(field.Type, &fieldType); err != nil {
+			return err
+		}
+		targetType, ok := targetTypes[strings.ToLower(field.Name)]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("field '%s' is missing from the target", field.Name))
+			continue
+		}
+		if targetType != fieldType.Name {
+			problems = append(problems, fmt.Sprintf("field '%s' is %s in the dataset but %s in the target", field.Name, fieldType.Name, targetType))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("target dataset '%s' is not compatible with dataset '%s':\n%s", targetId, datasetId, strings.Join(problems, "\n"))
+	}
+	return nil
+}
+
+// Frozen copies of the version 0 schemas, where all field lists were ordered
+// lists. They must not change with the current schemas, as they describe the
+// state written by earlier versions of the provider.
+func resourceRawReflectionV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"dataset_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"display_fields": {
+				Type:     schema.TypeList,
+				Required: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "Raw",
+			},
+			"enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"distribution_fields": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"partition_fields": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"sort_fields": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"partition_distribution_strategy": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "CONSOLIDATED",
+			},
+		},
+	}
+}
+
+func resourceAggregationReflectionV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"dataset_id": {
+				Type:     schema.TypeString,
+				Required: true,
+				ForceNew: true,
+			},
+			"name": {
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"enabled": {
+				Type:     schema.TypeBool,
+				Optional: true,
+				Default:  true,
+			},
+			"dimension_fields": {
+				Type:     schema.TypeList,
+				Required: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"timestamp_date_dimension_fields": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"measure_fields_sum": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"distribution_fields": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"partition_fields": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"sort_fields": {
+				Type:     schema.TypeList,
+				Optional: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"partition_distribution_strategy": {
+				Type:     schema.TypeString,
+				Optional: true,
+				Default:  "CONSOLIDATED",
+			},
+		},
+	}
+}
+
+// Lists and sets share the same JSON state representation, so the state can
+// be carried over unchanged.
+func upgradeReflectionStateV0(ctx context.Context, rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	return rawState, nil
+}
@@ -0,0 +1,149 @@
+package dremio
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	dapi "github.com/saltxwater/go-dremio-api-client"
+)
+
+func TestPartitionFieldsRoundTrip(t *testing.T) {
+	s := makeReflectionPartitionSchema(map[string]*schema.Schema{})
+	d := schema.TestResourceDataRaw(t, s, map[string]interface{}{
+		"partition_field": []interface{}{
+			map[string]interface{}{"name": "region"},
+			map[string]interface{}{"name": "created", "transform": "DAY"},
+			map[string]interface{}{"name": "id", "transform": "BUCKET", "transform_arg": 16},
+		},
+	})
+
+	fields := getPartitionFields(d)
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`{"name":"region"}`,
+		`{"name":"created","transform":{"type":"DAY"}}`,
+		`{"name":"id","transform":{"type":"BUCKET","bucketTransform":{"bucketCount":16}}}`,
+	} {
+		if !containsJson(string(raw), want) {
+			t.Errorf("partition fields %s do not contain %s", raw, want)
+		}
+	}
+
+	if err := setPartitionFields(d, fields); err != nil {
+		t.Fatal(err)
+	}
+	if got := d.Get("partition_field").(*schema.Set).Len(); got != 3 {
+		t.Errorf("read back %d partition_field blocks, want 3", got)
+	}
+
+	// Plain names stay in partition_fields when partition_field is not used
+	plain := schema.TestResourceDataRaw(t, s, map[string]interface{}{})
+	if err := setPartitionFields(plain, []apiReflectionPartitionField{{Name: "region"}}); err != nil {
+		t.Fatal(err)
+	}
+	if plain.Get("partition_fields").(*schema.Set).Len() != 1 || plain.Get("partition_field").(*schema.Set).Len() != 0 {
+		t.Errorf("plain names were not read back into partition_fields")
+	}
+}
+
+func containsJson(list string, item string) bool {
+	var items []json.RawMessage
+	if err := json.Unmarshal([]byte(list), &items); err != nil {
+		return false
+	}
+	for _, raw := range items {
+		if string(raw) == item {
+			return true
+		}
+	}
+	return false
+}
+
+func TestCheckExternalReflectionFields(t *testing.T) {
+	datasets := map[string]string{
+		"/api/v3/catalog/query":   `{"fields":[{"name":"id","type":{"name":"BIGINT"}},{"name":"day","type":{"name":"DATE"}}]}`,
+		"/api/v3/catalog/good":    `{"fields":[{"name":"ID","type":{"name":"BIGINT"}},{"name":"day","type":{"name":"DATE"}},{"name":"extra","type":{"name":"VARCHAR"}}]}`,
+		"/api/v3/catalog/retyped": `{"fields":[{"name":"id","type":{"name":"INTEGER"}}]}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := datasets[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	api := newApiConnection(server.URL, dapi.Config{ApiKey: "key"})
+	ctx := context.Background()
+
+	if err := checkExternalReflectionFields(ctx, api, "query", "good"); err != nil {
+		t.Errorf("compatible target rejected: %s", err)
+	}
+	err := checkExternalReflectionFields(ctx, api, "query", "retyped")
+	if err == nil {
+		t.Fatal("incompatible target accepted")
+	}
+	for _, want := range []string{"'id' is BIGINT in the dataset but INTEGER", "'day' is missing"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	if err := checkExternalReflectionFields(ctx, api, "query", "missing"); !isApiStatus(err, http.StatusNotFound) {
+		t.Errorf("missing target error = %v, want status 404", err)
+	}
+}
+
+func TestValidateReflectionFieldsOnApply(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/catalog/ds" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"fields":[{"name":"region","type":{"name":"VARCHAR"}},{"name":"tags","type":{"name":"LIST"}}]}`))
+	}))
+	defer server.Close()
+	api := newApiConnection(server.URL, dapi.Config{ApiKey: "key"})
+	ctx := context.Background()
+	s := resourceRawReflection().Schema
+
+	valid := schema.TestResourceDataRaw(t, s, map[string]interface{}{
+		"dataset_id":     "ds",
+		"display_fields": []interface{}{"Region"},
+	})
+	if err := validateRawReflectionFields(ctx, api, valid); err != nil {
+		t.Errorf("valid fields rejected: %s", err)
+	}
+
+	invalid := schema.TestResourceDataRaw(t, s, map[string]interface{}{
+		"dataset_id":       "ds",
+		"display_fields":   []interface{}{"region", "regoin"},
+		"partition_fields": []interface{}{"tags"},
+	})
+	err := validateRawReflectionFields(ctx, api, invalid)
+	if err == nil {
+		t.Fatal("invalid fields accepted")
+	}
+	for _, want := range []string{"display_fields.1: field 'regoin' does not exist", "field 'tags' of type LIST cannot be used for partitioning"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+
+	// Only a plan may go ahead without the dataset
+	missing := schema.TestResourceDataRaw(t, s, map[string]interface{}{
+		"dataset_id":     "other",
+		"display_fields": []interface{}{"region"},
+	})
+	if err := validateRawReflectionFields(ctx, api, missing); !isApiStatus(err, http.StatusNotFound) {
+		t.Errorf("missing dataset error = %v, want status 404", err)
+	}
+}
@@ -0,0 +1,438 @@
+package dremio
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+)
+
+var sqlOperators = []string{"<>", "<=", ">=", "!=", "||", "::"}
+
+func isSqlWordChar(ch byte) bool {
+	return ch == '_' || ch == '$' || ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= 0x80
+}
+
+type sqlToken struct {
+	text   string
+	offset int
+}
+
+// Splits SQL into tokens, dropping whitespace and comments. Unquoted words are
+// upper cased as Dremio treats keywords and identifiers case-insensitively,
+// quoted identifiers and string literals are kept verbatim. Unterminated
+// quotes and comments are reported, along with the tokens scanned so far.
+func scanSql(sql string) ([]sqlToken, error) {
+	tokens := make([]sqlToken, 0)
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			i++
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 1
+			}
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				return tokens, newSqlError(sql, i, "unterminated comment")
+			}
+			i += end + 4
+		case ch == '\'' || ch == '"' || ch == '`':
+			j := i + 1
+			for j < len(sql) {
+				if sql[j] == ch {
+					// A doubled quote is an escaped quote
+					if j+1 < len(sql) && sql[j+1] == ch {
+						j += 2
+						continue
+					}
+					break
+				}
+				j++
+			}
+			if j >= len(sql) {
+				tokens = append(tokens, sqlToken{text: sql[i:], offset: i})
+				return tokens, newSqlError(sql, i, fmt.Sprintf("unterminated %c quote", ch))
+			}
+			tokens = append(tokens, sqlToken{text: sql[i : j+1], offset: i})
+			i = j + 1
+		case isSqlWordChar(ch):
+			j := i + 1
+			for j < len(sql) && isSqlWordChar(sql[j]) {
+				j++
+			}
+			tokens = append(tokens, sqlToken{text: strings.ToUpper(sql[i:j]), offset: i})
+			i = j
+		default:
+			text := sql[i : i+1]
+			for _, op := range sqlOperators {
+				if strings.HasPrefix(sql[i:], op) {
+					text = op
+					break
+				}
+			}
+			tokens = append(tokens, sqlToken{text: text, offset: i})
+			i += len(text)
+		}
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].text == ";" {
+		tokens = tokens[:len(tokens)-1]
+	}
+	return tokens, nil
+}
+
+func tokenizeSql(sql string) []string {
+	scanned, _ := scanSql(sql)
+	tokens := make([]string, len(scanned))
+	for i, token := range scanned {
+		tokens[i] = token.text
+	}
+	return tokens
+}
+
+func newSqlError(sql string, offset int, msg string) error {
+	line := strings.Count(sql[:offset], "\n") + 1
+	column := offset - strings.LastIndexByte(sql[:offset], '\n')
+	return fmt.Errorf("invalid sql at line %d, column %d: %s", line, column, msg)
+}
+
+// Catches structural mistakes locally so they are reported at plan time
+// instead of part way through an apply.
+func validateSql(sql string) error {
+	tokens, err := scanSql(sql)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return fmt.Errorf("invalid sql: statement is empty")
+	}
+	switch tokens[0].text {
+	case "SELECT", "WITH", "VALUES", "(":
+	default:
+		return newSqlError(sql, tokens[0].offset, fmt.Sprintf("expected SELECT, WITH or VALUES, got '%s'", tokens[0].text))
+	}
+	open := make([]sqlToken, 0)
+	for _, token := range tokens {
+		switch token.text {
+		case "(":
+			open = append(open, token)
+		case ")":
+			if len(open) == 0 {
+				return newSqlError(sql, token.offset, "unexpected ')'")
+			}
+			open = open[:len(open)-1]
+		case ";":
+			return newSqlError(sql, token.offset, "only a single statement is allowed")
+		}
+	}
+	if len(open) > 0 {
+		return newSqlError(sql, open[len(open)-1].offset, "unclosed '('")
+	}
+	return nil
+}
+
+const sqlValidationPollInterval = time.Second
+
+// Server errors count lines from the EXPLAIN PLAN FOR line added before the
+// statement.
+var sqlErrorLinePattern = regexp.MustCompile(`line (\d+), column (\d+)`)
+
+func remapSqlErrorLines(msg string) string {
+	return sqlErrorLinePattern.ReplaceAllStringFunc(msg, func(match string) string {
+		parts := sqlErrorLinePattern.FindStringSubmatch(match)
+		line, _ := strconv.Atoi(parts[1])
+		return fmt.Sprintf("line %d, column %s", line-1, parts[2])
+	})
+}
+
+// Has Dremio plan the statement without running it, which also reports
+// unknown tables and columns. Statements reading datasets that do not exist
+// yet, such as ones created in the same apply, cannot be planned and only get
+// the local check.
+func validateSqlOnServer(ctx context.Context, api *apiConnection, sql string, sqlContext []string) error {
+	if err := validateSql(sql); err != nil {
+		return err
+	}
+	for _, qp := range extractReferencedPaths(sql, sqlContext) {
+		_, err := api.getCatalogEntityByPath(ctx, splitQueryPath(qp))
+		if isApiStatus(err, http.StatusNotFound) {
+			log.Printf("Not validating sql on the server, %s does not exist yet", qp)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	jobId, err := api.submitSql(ctx, "EXPLAIN PLAN FOR\n"+sql, sqlContext)
+	if err != nil {
+		return err
+	}
+	job, err := api.waitForJob(ctx, jobId, sqlValidationPollInterval)
+	if err != nil {
+		return err
+	}
+	switch job.JobState {
+	case "FAILED":
+		return fmt.Errorf("invalid sql: %s", remapSqlErrorLines(job.ErrorMessage))
+	case "CANCELED":
+		return fmt.Errorf("validating sql: job %s was canceled", jobId)
+	}
+	return nil
+}
+
+func normalizeSql(sql string) string {
+	return strings.Join(tokenizeSql(sql), " ")
+}
+
+func suppressEquivalentSqlDiff(k, old, new string, d *schema.ResourceData) bool {
+	return normalizeSql(old) == normalizeSql(new)
+}
+
+func renderSqlFile(path string, vars map[string]interface{}) (string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(path).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return "", err
+	}
+	var sql strings.Builder
+	if err := tmpl.Execute(&sql, vars); err != nil {
+		return "", err
+	}
+	return sql.String(), nil
+}
+
+var sqlClauseKeywords = map[string]bool{
+	"WHERE": true, "GROUP": true, "HAVING": true, "ORDER": true, "LIMIT": true, "OFFSET": true,
+	"FETCH": true, "UNION": true, "EXCEPT": true, "INTERSECT": true, "ON": true, "USING": true,
+	"JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true,
+	"OUTER": true, "NATURAL": true, "WINDOW": true, "QUALIFY": true, "AT": true, "LATERAL": true,
+	"TABLESAMPLE": true, "FOR": true,
+}
+
+// Modify the table reference they follow, e.g. TABLESAMPLE BERNOULLI (10),
+// FOR SYSTEM_TIME AS OF ... or AT BRANCH main, rather than ending it.
+var sqlTableModifierKeywords = map[string]bool{
+	"AT": true, "TABLESAMPLE": true, "FOR": true,
+}
+
+func sqlIdentifier(sql string, token sqlToken) (string, bool) {
+	switch token.text[0] {
+	case '"':
+		return strings.ReplaceAll(token.text[1:len(token.text)-1], `""`, `"`), true
+	case '`':
+		return strings.ReplaceAll(token.text[1:len(token.text)-1], "``", "`"), true
+	}
+	if isSqlWordChar(token.text[0]) {
+		return sql[token.offset : token.offset+len(token.text)], true
+	}
+	return "", false
+}
+
+// Marks the tokens that belong to a query rather than to the arguments of a
+// function call, so keywords such as FROM in EXTRACT(YEAR FROM x) or
+// TRIM(BOTH ' ' FROM x) are not mistaken for a clause.
+func markQueryTokens(tokens []sqlToken) []bool {
+	inQuery := make([]bool, len(tokens))
+	scopes := []bool{true}
+	for i, token := range tokens {
+		switch token.text {
+		case "(":
+			next := ""
+			if i+1 < len(tokens) {
+				next = tokens[i+1].text
+			}
+			scopes = append(scopes, next == "SELECT" || next == "WITH" || next == "VALUES" || next == "(")
+		case ")":
+			if len(scopes) > 1 {
+				scopes = scopes[:len(scopes)-1]
+			}
+		}
+		inQuery[i] = scopes[len(scopes)-1]
+	}
+	return inQuery
+}
+
+// Returns the index of the token after the bracketed list starting at i.
+func skipSqlBrackets(tokens []sqlToken, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return i
+}
+
+func findCommonTableExpressions(sql string, tokens []sqlToken) map[string]bool {
+	ctes := make(map[string]bool)
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i].text != "WITH" && tokens[i].text != "," {
+			continue
+		}
+		j := i + 1
+		if tokens[i].text == "WITH" && tokens[j].text == "RECURSIVE" {
+			j++
+		}
+		if j >= len(tokens) {
+			break
+		}
+		name, ok := sqlIdentifier(sql, tokens[j])
+		if !ok {
+			continue
+		}
+		// Skip an optional column list
+		k := j + 1
+		if k < len(tokens) && tokens[k].text == "(" {
+			k = skipSqlBrackets(tokens, k)
+		}
+		if k < len(tokens) && tokens[k].text == "AS" {
+			ctes[strings.ToUpper(name)] = true
+		}
+	}
+	return ctes
+}
+
+// Collects the dataset paths read by FROM and JOIN clauses. Subqueries, table
+// functions and references to common table expressions are skipped. Single
+// part names are resolved against the SQL context, as Dremio does.
+func extractReferencedPaths(sql string, sqlContext []string) []string {
+	tokens, _ := scanSql(sql)
+	ctes := findCommonTableExpressions(sql, tokens)
+	inQuery := markQueryTokens(tokens)
+
+	// Offset of the first reference to each path
+	seen := make(map[string]int)
+	paths := make([]string, 0)
+	for i := 0; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "FROM":
+			// IS [NOT] DISTINCT FROM is a comparison, not a clause
+			if !inQuery[i] || i > 0 && tokens[i-1].text == "DISTINCT" {
+				continue
+			}
+		case "JOIN":
+		default:
+			continue
+		}
+		// A separate cursor is used for the table references, so FROM clauses
+		// inside skipped brackets are still visited by the outer loop
+		j := i + 1
+		for {
+			if j < len(tokens) && tokens[j].text == "LATERAL" {
+				j++
+			}
+			start := j
+			path := make([]string, 0)
+			for j < len(tokens) {
+				name, ok := sqlIdentifier(sql, tokens[j])
+				if !ok {
+					break
+				}
+				path = append(path, name)
+				if j+1 < len(tokens) && tokens[j+1].text == "." {
+					j += 2
+					continue
+				}
+				j++
+				break
+			}
+			// A derived table, or the arguments of a table function
+			isFunction := j < len(tokens) && tokens[j].text == "("
+			if isFunction {
+				j = skipSqlBrackets(tokens, j)
+			}
+			isCte := len(path) == 1 && ctes[strings.ToUpper(path[0])]
+			if len(path) > 0 && !isFunction && !isCte {
+				if len(path) == 1 && len(sqlContext) > 0 {
+					path = append(append([]string{}, sqlContext...), path...)
+				}
+				qp := getQueryPath(path)
+				if offset, ok := seen[qp]; !ok {
+					seen[qp] = tokens[start].offset
+					paths = append(paths, qp)
+				} else if tokens[start].offset < offset {
+					seen[qp] = tokens[start].offset
+				}
+			}
+			// Skip the alias, its optional column list and any table modifiers,
+			// up to the next comma separated table or the end of the reference
+			for j < len(tokens) {
+				text := tokens[j].text
+				if text == "," || text == ")" || text == ";" || sqlClauseKeywords[text] && !sqlTableModifierKeywords[text] {
+					break
+				}
+				if text == "(" {
+					j = skipSqlBrackets(tokens, j)
+					continue
+				}
+				j++
+			}
+			if j >= len(tokens) || tokens[j].text != "," {
+				break
+			}
+			j++
+		}
+	}
+	// Tables after a derived table are found before the ones inside it
+	sort.SliceStable(paths, func(a, b int) bool { return seen[paths[a]] < seen[paths[b]] })
+	return paths
+}
+
+// Dremio's lineage graph has the canonical paths but no order, and the local
+// parse can be fooled by unusual SQL, so paths are listed as the graph has
+// them in the order the parse found them.
+func readReferencedPaths(ctx context.Context, api *apiConnection, vdsId string, sql string, sqlContext []string) ([]string, error) {
+	local := extractReferencedPaths(sql, sqlContext)
+	parents, err := api.getDatasetParents(ctx, vdsId)
+	if isApiStatus(err, http.StatusNotFound) {
+		return local, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	canonical := make(map[string]string)
+	for _, parent := range parents {
+		qp := getQueryPath(parent)
+		canonical[strings.ToLower(qp)] = qp
+	}
+	paths := make([]string, 0, len(parents))
+	for _, qp := range local {
+		if match, ok := canonical[strings.ToLower(qp)]; ok {
+			paths = append(paths, match)
+			delete(canonical, strings.ToLower(qp))
+		} else {
+			log.Printf("Leaving %s out of referenced_paths, the lineage graph does not list it", qp)
+		}
+	}
+	for _, parent := range parents {
+		qp := getQueryPath(parent)
+		if _, ok := canonical[strings.ToLower(qp)]; ok {
+			paths = append(paths, qp)
+			delete(canonical, strings.ToLower(qp))
+		}
+	}
+	return paths, nil
+}
@@ -0,0 +1,201 @@
+package dremio
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	dapi "github.com/saltxwater/go-dremio-api-client"
+)
+
+func TestExtractReferencedPaths(t *testing.T) {
+	cases := []struct {
+		name    string
+		sql     string
+		context []string
+		want    []string
+	}{
+		{
+			name: "single table",
+			sql:  `SELECT * FROM "Space"."Folder"."Table"`,
+			want: []string{`"Space"."Folder"."Table"`},
+		},
+		{
+			name: "joins and aliases",
+			sql:  `SELECT * FROM s.a AS x JOIN s.b y ON x.id = y.id LEFT JOIN s.c USING (id)`,
+			want: []string{`"s"."a"`, `"s"."b"`, `"s"."c"`},
+		},
+		{
+			name: "comma separated tables",
+			sql:  `SELECT * FROM s.a x, s.b WHERE x.id = 1`,
+			want: []string{`"s"."a"`, `"s"."b"`},
+		},
+		{
+			name: "duplicates reported once",
+			sql:  `SELECT * FROM s.a UNION ALL SELECT * FROM s.a`,
+			want: []string{`"s"."a"`},
+		},
+		{
+			name: "subquery",
+			sql:  `SELECT * FROM (SELECT id FROM s.a) t WHERE id IN (SELECT id FROM s.b)`,
+			want: []string{`"s"."a"`, `"s"."b"`},
+		},
+		{
+			name: "table function",
+			sql:  `SELECT * FROM TABLE(s.f(1))`,
+			want: []string{},
+		},
+		{
+			name: "extract",
+			sql:  `SELECT EXTRACT(YEAR FROM order_date) FROM s.orders`,
+			want: []string{`"s"."orders"`},
+		},
+		{
+			name: "trim and substring",
+			sql:  `SELECT TRIM(BOTH ' ' FROM name), SUBSTRING(code FROM 2) FROM s.a`,
+			want: []string{`"s"."a"`},
+		},
+		{
+			name: "function inside subquery",
+			sql:  `SELECT * FROM (SELECT EXTRACT(DAY FROM d) AS x FROM s.a)`,
+			want: []string{`"s"."a"`},
+		},
+		{
+			name: "is distinct from",
+			sql:  `SELECT * FROM s.a WHERE x IS DISTINCT FROM y OR x IS NOT DISTINCT FROM z`,
+			want: []string{`"s"."a"`},
+		},
+		{
+			name: "common table expressions",
+			sql:  `WITH r AS (SELECT * FROM s.a), q (id) AS (SELECT id FROM r) SELECT * FROM q JOIN s.b ON q.id = s.b.id`,
+			want: []string{`"s"."a"`, `"s"."b"`},
+		},
+		{
+			name: "recursive common table expression",
+			sql:  `WITH RECURSIVE r AS (SELECT 1 AS n UNION ALL SELECT n + 1 FROM r) SELECT * FROM r`,
+			want: []string{},
+		},
+		{
+			name: "lateral flatten",
+			sql:  `SELECT f.value FROM s.a, LATERAL FLATTEN(s.a.items) AS f(value) JOIN s.b ON true`,
+			want: []string{`"s"."a"`, `"s"."b"`},
+		},
+		{
+			name: "tablesample",
+			sql:  `SELECT * FROM s.a AS x TABLESAMPLE BERNOULLI (10), s.b`,
+			want: []string{`"s"."a"`, `"s"."b"`},
+		},
+		{
+			name: "for system_time",
+			sql:  `SELECT * FROM s.a FOR SYSTEM_TIME AS OF TIMESTAMP '2021-01-01 00:00:00' AS x, s.b y`,
+			want: []string{`"s"."a"`, `"s"."b"`},
+		},
+		{
+			name: "derived table followed by a comma separated table",
+			sql:  `SELECT * FROM (SELECT id FROM s.a) t (n), s.b WHERE t.n = s.b.id`,
+			want: []string{`"s"."a"`, `"s"."b"`},
+		},
+		{
+			name: "at branch",
+			sql:  `SELECT * FROM s.a AT BRANCH dev x JOIN s.b AT TAG v1 ON x.id = s.b.id`,
+			want: []string{`"s"."a"`, `"s"."b"`},
+		},
+		{
+			name:    "unqualified name resolved against context",
+			sql:     `SELECT * FROM a JOIN "Other".b ON a.id = b.id`,
+			context: []string{"Space", "Folder"},
+			want:    []string{`"Space"."Folder"."a"`, `"Other"."b"`},
+		},
+		{
+			name: "quoted identifiers and comments",
+			sql:  "SELECT * -- FROM s.ignored\nFROM \"My Space\".\"a \"\"b\"\"\" /* FROM s.other */",
+			want: []string{`"My Space"."a "b""`},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := extractReferencedPaths(tc.sql, tc.context)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("extractReferencedPaths(%q) = %q, want %q", tc.sql, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestValidateSqlOnServer(t *testing.T) {
+	submitted := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v3/catalog/by-path/src/t":
+			w.Write([]byte(`{"id":"t","entityType":"dataset","type":"PHYSICAL_DATASET"}`))
+		case strings.HasPrefix(r.URL.Path, "/api/v3/catalog/by-path/"):
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/api/v3/sql":
+			submitted++
+			var in struct {
+				Sql string `json:"sql"`
+			}
+			json.NewDecoder(r.Body).Decode(&in)
+			if strings.Contains(in.Sql, "missing_column") {
+				w.Write([]byte(`{"id":"failed"}`))
+			} else {
+				w.Write([]byte(`{"id":"ok"}`))
+			}
+		case r.URL.Path == "/api/v3/job/failed":
+			w.Write([]byte(`{"jobState":"FAILED","errorMessage":"From line 2, column 8 to line 2, column 21: Column 'missing_column' not found"}`))
+		case r.URL.Path == "/api/v3/job/ok":
+			w.Write([]byte(`{"jobState":"COMPLETED"}`))
+		}
+	}))
+	defer server.Close()
+	api := newApiConnection(server.URL, dapi.Config{ApiKey: "key"})
+	ctx := context.Background()
+
+	if err := validateSqlOnServer(ctx, api, "SELECT a FROM src.t", nil); err != nil {
+		t.Errorf("valid sql failed: %s", err)
+	}
+	err := validateSqlOnServer(ctx, api, "SELECT a\nFROM new_view", []string{"src"})
+	if err != nil {
+		t.Errorf("sql reading a dataset that does not exist yet failed: %s", err)
+	}
+	if submitted != 1 {
+		t.Errorf("submitted %d jobs, want only the one for existing datasets", submitted)
+	}
+	err = validateSqlOnServer(ctx, api, "SELECT\nmissing_column FROM t", []string{"src"})
+	want := "invalid sql: From line 1, column 8 to line 1, column 21: Column 'missing_column' not found"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %s", err, want)
+	}
+	if err := validateSqlOnServer(ctx, api, "SELECT (1", nil); err == nil || submitted != 2 {
+		t.Errorf("error = %v with %d jobs, want the local error without a job", err, submitted)
+	}
+}
+
+func TestReadReferencedPaths(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/catalog/view/graph" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"parents":[{"path":["S","b"]},{"path":["S","c"]},{"path":["S","a"]}]}`))
+	}))
+	defer server.Close()
+	api := newApiConnection(server.URL, dapi.Config{ApiKey: "key"})
+	ctx := context.Background()
+	sql := `SELECT * FROM s.a JOIN s.b ON true JOIN s.missing ON true`
+
+	got, err := readReferencedPaths(ctx, api, "view", sql, nil)
+	want := []string{`"S"."a"`, `"S"."b"`, `"S"."c"`}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("paths from the graph = %q, %v, want %q", got, err, want)
+	}
+	got, err = readReferencedPaths(ctx, api, "other", sql, nil)
+	want = []string{`"s"."a"`, `"s"."b"`, `"s"."missing"`}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("paths without a graph = %q, %v, want %q", got, err, want)
+	}
+}
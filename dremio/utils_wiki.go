@@ -0,0 +1,284 @@
+package dremio
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/schema"
+	dapi "github.com/saltxwater/go-dremio-api-client"
+)
+
+// A part of an entity's wiki generated by the provider. Each lives between
+// its own markers, so several resources can share one wiki without
+// overwriting each other's content.
+type wikiSection struct {
+	start string
+	end   string
+}
+
+var wikiColumnsSection = wikiSection{
+	start: "<!-- terraform-provider-dremio:columns:start -->",
+	end:   "<!-- terraform-provider-dremio:columns:end -->",
+}
+
+var wikiDocSection = wikiSection{
+	start: "<!-- terraform-provider-dremio:doc:start -->",
+	end:   "<!-- terraform-provider-dremio:doc:end -->",
+}
+
+// All generated sections, which dremio_entity_wiki leaves in place.
+var wikiSections = []wikiSection{wikiDocSection, wikiColumnsSection}
+
+const wikiSectionSeparator = "\n\n"
+
+func (s wikiSection) find(text string) (int, int, bool) {
+	start := strings.Index(text, s.start)
+	if start < 0 {
+		return 0, 0, false
+	}
+	end := strings.Index(text[start:], s.end)
+	if end < 0 {
+		return 0, 0, false
+	}
+	return start, start + end + len(s.end), true
+}
+
+func (s wikiSection) wrap(content string) string {
+	return s.start + "\n" + content + "\n" + s.end
+}
+
+// Returns the content between the markers, as passed to wrap.
+func (s wikiSection) unwrap(text string) string {
+	block := s.extract(text)
+	if block == "" {
+		return ""
+	}
+	content := strings.TrimPrefix(block[len(s.start):len(block)-len(s.end)], "\n")
+	return strings.TrimSuffix(content, "\n")
+}
+
+// Returns the section including its markers, or an empty string.
+func (s wikiSection) extract(text string) string {
+	start, end, ok := s.find(text)
+	if !ok {
+		return ""
+	}
+	return text[start:end]
+}
+
+// Replaces the section with block, appending it if not present yet and
+// removing it along with its separator when block is empty. The rest of the
+// text is kept byte for byte.
+func (s wikiSection) merge(text string, block string) string {
+	start, end, ok := s.find(text)
+	if ok {
+		if block != "" {
+			return text[:start] + block + text[end:]
+		}
+		if strings.HasSuffix(text[:start], wikiSectionSeparator) {
+			start -= len(wikiSectionSeparator)
+		} else if strings.HasPrefix(text[end:], wikiSectionSeparator) {
+			end += len(wikiSectionSeparator)
+		}
+		return text[:start] + text[end:]
+	}
+	if block == "" {
+		return text
+	}
+	if text == "" {
+		return block
+	}
+	return text + wikiSectionSeparator + block
+}
+
+// Returns the wiki without any generated sections.
+func stripWikiSections(text string) string {
+	for _, s := range wikiSections {
+		text = s.merge(text, "")
+	}
+	return text
+}
+
+// Appends the generated sections found in current to text.
+func keepWikiSections(text string, current string) string {
+	for _, s := range wikiSections {
+		text = s.merge(text, s.extract(current))
+	}
+	return text
+}
+
+func isWikiEdgeSpace(r rune) bool {
+	return r == ' ' || r == '\t'
+}
+
+// Escapes text for a table cell so it reads back unchanged. Markdown special
+// characters are backslash escaped, line breaks become <br> and leading or
+// trailing whitespace, which would otherwise be trimmed, becomes an entity.
+func escapeWikiCell(text string, escapeComma bool) string {
+	runes := []rune(text)
+	first, last := 0, len(runes)-1
+	for first <= last && isWikiEdgeSpace(runes[first]) {
+		first++
+	}
+	for last >= first && isWikiEdgeSpace(runes[last]) {
+		last--
+	}
+	var sb strings.Builder
+	for i, r := range runes {
+		switch {
+		case i < first || i > last:
+			sb.WriteString(fmt.Sprintf("&#%d;", r))
+		case r == '\\' || r == '|' || r == '&' || r == '<' || r == ',' && escapeComma:
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		case r == '\n':
+			sb.WriteString("<br>")
+		case r == '\r':
+			sb.WriteString("&#13;")
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+func unescapeWikiCell(raw string) string {
+	var sb strings.Builder
+	for i := 0; i < len(raw); {
+		if raw[i] == '\\' && i+1 < len(raw) {
+			sb.WriteByte(raw[i+1])
+			i += 2
+			continue
+		}
+		if strings.HasPrefix(raw[i:], "<br>") {
+			sb.WriteByte('\n')
+			i += len("<br>")
+			continue
+		}
+		if strings.HasPrefix(raw[i:], "&#") {
+			if end := strings.IndexByte(raw[i:], ';'); end > 2 {
+				if code, err := strconv.Atoi(raw[i+2 : i+end]); err == nil {
+					sb.WriteRune(rune(code))
+					i += end + 1
+					continue
+				}
+			}
+		}
+		sb.WriteByte(raw[i])
+		i++
+	}
+	return sb.String()
+}
+
+// Splits on sep where it is not backslash escaped, leaving the parts escaped.
+func splitWikiEscaped(raw string, sep byte) []string {
+	parts := make([]string, 0)
+	start := 0
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' {
+			i++
+			continue
+		}
+		if raw[i] == sep {
+			parts = append(parts, raw[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, raw[start:])
+}
+
+func splitWikiRow(row string) []string {
+	parts := splitWikiEscaped(strings.TrimPrefix(strings.TrimSpace(row), "|"), '|')
+	// A closing pipe leaves an empty part behind
+	if len(parts) > 1 && strings.TrimSpace(parts[len(parts)-1]) == "" {
+		parts = parts[:len(parts)-1]
+	}
+	return parts
+}
+
+func renderColumnsWiki(columns []interface{}) string {
+	if len(columns) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString(wikiColumnsSection.start + "\n")
+	sb.WriteString("## Columns\n\n")
+	sb.WriteString("| Column | Description | Tags |\n")
+	sb.WriteString("| --- | --- | --- |\n")
+	for _, raw := range columns {
+		column := raw.(map[string]interface{})
+		tags := interfaceListToStringList(column["tags"].([]interface{}))
+		for i, tag := range tags {
+			tags[i] = escapeWikiCell(tag, true)
+		}
+		sb.WriteString(fmt.Sprintf("| %s | %s | %s |\n",
+			escapeWikiCell(column["name"].(string), false),
+			escapeWikiCell(column["description"].(string), false),
+			strings.Join(tags, ", ")))
+	}
+	sb.WriteString(wikiColumnsSection.end)
+	return sb.String()
+}
+
+func parseColumnsWiki(text string) []interface{} {
+	columns := make([]interface{}, 0)
+	section := wikiColumnsSection.extract(text)
+	rows := 0
+	for _, line := range strings.Split(section, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "|") {
+			continue
+		}
+		// The first two rows are the header and its separator
+		rows++
+		if rows <= 2 {
+			continue
+		}
+		cells := splitWikiRow(line)
+		if len(cells) < 3 {
+			continue
+		}
+		tags := make([]string, 0)
+		for _, tag := range splitWikiEscaped(cells[2], ',') {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, unescapeWikiCell(tag))
+			}
+		}
+		columns = append(columns, map[string]interface{}{
+			"name":        unescapeWikiCell(strings.TrimSpace(cells[0])),
+			"description": unescapeWikiCell(strings.TrimSpace(cells[1])),
+			"tags":        tags,
+		})
+	}
+	return columns
+}
+
+func updateColumnsWiki(c *dapi.Client, d *schema.ResourceData) error {
+	entityId := d.Id()
+	text := ""
+	wikiVersion := 0
+	wikiBody, err := c.GetEntityWiki(entityId)
+	if err != nil {
+		// Assume failed because no wiki exists yet
+		log.Printf("No existing wiki for entity '%s': %s", entityId, err)
+	} else {
+		text = wikiBody.Text
+		wikiVersion = wikiBody.Version
+	}
+
+	merged := wikiColumnsSection.merge(text, renderColumnsWiki(d.Get("column").([]interface{})))
+	if merged == text {
+		return nil
+	}
+	return c.SetEntityWiki(entityId, merged, wikiVersion)
+}
+
+func readColumnsWiki(c *dapi.Client, d *schema.ResourceData) error {
+	text := ""
+	wikiBody, err := c.GetEntityWiki(d.Id())
+	if err == nil {
+		text = wikiBody.Text
+	}
+	return d.Set("column", parseColumnsWiki(text))
+}
@@ -0,0 +1,135 @@
+package dremio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func makeWikiColumn(name string, description string, tags ...string) map[string]interface{} {
+	rawTags := make([]interface{}, len(tags))
+	for i, tag := range tags {
+		rawTags[i] = tag
+	}
+	return map[string]interface{}{
+		"name":        name,
+		"description": description,
+		"tags":        rawTags,
+	}
+}
+
+func TestColumnsWikiRoundTrip(t *testing.T) {
+	cases := []struct {
+		name   string
+		column map[string]interface{}
+	}{
+		{"plain", makeWikiColumn("id", "Primary key", "pii", "key")},
+		{"no description or tags", makeWikiColumn("id", "")},
+		{"pipes", makeWikiColumn("a|b", "x | y", "t|u")},
+		{"backslashes", makeWikiColumn(`C:\dir`, `C:\tmp\|x`, `a\`)},
+		{"trailing backslash", makeWikiColumn("id", `ends with \`, "next")},
+		{"named like the header", makeWikiColumn("Column", "Description", "Tags")},
+		{"separator like values", makeWikiColumn("---", "---", "---")},
+		{"edge whitespace", makeWikiColumn(" id", "  padded\t", " t ")},
+		{"line breaks", makeWikiColumn("id", "line one\nline two\r\nline three")},
+		{"literal markup", makeWikiColumn("id", "<br> and &#32; and &amp;")},
+		{"comma in tag", makeWikiColumn("id", "a, b", "x,y", "z")},
+		{"unicode", makeWikiColumn("naïve", "日本語 ✓", "ü")},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			columns := []interface{}{makeWikiColumn("first", "before"), tc.column, makeWikiColumn("last", "after")}
+			got := parseColumnsWiki(renderColumnsWiki(columns))
+			want := make([]interface{}, len(columns))
+			for i, raw := range columns {
+				column := raw.(map[string]interface{})
+				want[i] = map[string]interface{}{
+					"name":        column["name"],
+					"description": column["description"],
+					"tags":        interfaceListToStringList(column["tags"].([]interface{})),
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("round trip changed columns\ngot:  %#v\nwant: %#v\nwiki:\n%s", got, want, renderColumnsWiki(columns))
+			}
+		})
+	}
+}
+
+func TestParseColumnsWiki(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want []interface{}
+	}{
+		{
+			name: "no section",
+			text: "| Column | Description | Tags |\n| --- | --- | --- |\n| id | key | |",
+			want: []interface{}{},
+		},
+		{
+			name: "hand edited spacing",
+			text: wikiColumnsSection.start + "\n## Columns\n\n|Column|Description|Tags|\n|-|-|-|\n|id|  key  |a ,b|\n" + wikiColumnsSection.end,
+			want: []interface{}{
+				map[string]interface{}{"name": "id", "description": "key", "tags": []string{"a", "b"}},
+			},
+		},
+		{
+			name: "surrounding text kept out",
+			text: "| not | a | column |\n\n" + wikiColumnsSection.start + "\n| Column | Description | Tags |\n| --- | --- | --- |\n| id | key | |\n" + wikiColumnsSection.end + "\n\n| also | not | this |",
+			want: []interface{}{
+				map[string]interface{}{"name": "id", "description": "key", "tags": []string{}},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := parseColumnsWiki(tc.text)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("parseColumnsWiki() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWikiSections(t *testing.T) {
+	block := renderColumnsWiki([]interface{}{makeWikiColumn("id", "key")})
+	for _, text := range []string{"", "Intro", "Intro\n", "Intro\n\n", "# Title\n\nBody\n"} {
+		merged := wikiColumnsSection.merge(text, block)
+		if got := wikiColumnsSection.extract(merged); got != block {
+			t.Errorf("extract(merge(%q)) = %q, want %q", text, got, block)
+		}
+		if got := stripWikiSections(merged); got != text {
+			t.Errorf("stripWikiSections(merge(%q)) = %q", text, got)
+		}
+		if got := keepWikiSections(text, merged); got != merged {
+			t.Errorf("keepWikiSections(%q) = %q, want %q", text, got, merged)
+		}
+		replaced := wikiColumnsSection.merge(merged, renderColumnsWiki([]interface{}{makeWikiColumn("id", "changed")}))
+		if got := stripWikiSections(replaced); got != text {
+			t.Errorf("stripWikiSections after replace of %q = %q", text, got)
+		}
+	}
+	if got := stripWikiSections(block + "\n\nOutro"); got != "Outro" {
+		t.Errorf("stripWikiSections with leading section = %q, want %q", got, "Outro")
+	}
+}
+
+func TestWikiDocSectionAlongsideColumns(t *testing.T) {
+	columns := renderColumnsWiki([]interface{}{makeWikiColumn("id", "key")})
+	text := wikiColumnsSection.merge("Hand written\n", columns)
+	for _, doc := range []string{"# Doc", "# Doc\n", "", "\n# Doc\n\n"} {
+		merged := wikiDocSection.merge(text, wikiDocSection.wrap(doc))
+		if got := wikiDocSection.unwrap(merged); got != doc {
+			t.Errorf("unwrap(wrap(%q)) = %q", doc, got)
+		}
+		if got := wikiColumnsSection.extract(merged); got != columns {
+			t.Errorf("doc section %q changed the column section to %q", doc, got)
+		}
+		if got := wikiDocSection.merge(merged, ""); got != text {
+			t.Errorf("removing doc section %q left %q, want %q", doc, got, text)
+		}
+		if got := stripWikiSections(merged); got != "Hand written\n" {
+			t.Errorf("stripWikiSections() = %q", got)
+		}
+	}
+}